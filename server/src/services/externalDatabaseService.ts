@@ -1,8 +1,18 @@
 import axios from 'axios';
+import { UriEncryption } from '../utils/uriEncryption';
 
 // Go Services Configuration - use Railway deployed service
 const DB_ACCESS_SERVICE = process.env.DB_ACCESS_SERVICE || 'https://universal-panel-production.up.railway.app';
 
+// Connection string field for the Go service: sent encrypted as mongo_uri_enc
+// when AES_KEY is configured, otherwise as cleartext mongo_uri
+function uriFields(uri: string): { mongo_uri: string } | { mongo_uri_enc: string } {
+  if (process.env.AES_KEY) {
+    return { mongo_uri_enc: UriEncryption.encrypt(uri) };
+  }
+  return { mongo_uri: uri };
+}
+
 // Helper function to properly encode MongoDB URI
 function encodeMongoUri(uri: string): string {
   // For URIs with passwords containing @, we need a different approach
@@ -140,7 +150,7 @@ export class ExternalDatabaseService {
   async testConnection(mongoUri: string, databaseName: string): Promise<boolean> {
     try {
       const response = await axios.post(`${DB_ACCESS_SERVICE}/allocate`, {
-        mongo_uri: mongoUri,
+        ...uriFields(mongoUri),
         database_name: databaseName
       }, {
         timeout: 15000
@@ -166,7 +176,7 @@ export class ExternalDatabaseService {
       console.log('  Collection:  ', request.collectionName);
       
       const response = await axios.post(`${DB_ACCESS_SERVICE}/method3/schema-analysis`, {
-        mongo_uri: encodedUri,
+        ...uriFields(encodedUri),
         database_name: request.databaseName,
         collection_name: request.collectionName
       }, {
@@ -223,7 +233,7 @@ export class ExternalDatabaseService {
     try {
       const encodedUri = encodeMongoUri(request.mongoUri);
       const response = await axios.post(`${DB_ACCESS_SERVICE}/method3/data-insert`, {
-        mongo_uri: encodedUri,
+        ...uriFields(encodedUri),
         database_name: request.databaseName,
         collection_name: request.collectionName,
         data: request.data
@@ -271,7 +281,7 @@ export class ExternalDatabaseService {
     try {
       const encodedUri = encodeMongoUri(request.mongoUri);
       const response = await axios.post(`${DB_ACCESS_SERVICE}/method3/data-get`, {
-        mongo_uri: encodedUri,
+        ...uriFields(encodedUri),
         database_name: request.databaseName,
         collection_name: request.collectionName,
         query: request.query || {},
@@ -326,7 +336,7 @@ export class ExternalDatabaseService {
     try {
       const encodedUri = encodeMongoUri(request.mongoUri);
       const response = await axios.post(`${DB_ACCESS_SERVICE}/method3/data-delete`, {
-        mongo_uri: encodedUri,
+        ...uriFields(encodedUri),
         database_name: request.databaseName,
         collection_name: request.collectionName,
         document_id: request.documentId
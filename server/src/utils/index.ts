@@ -6,4 +6,5 @@
 export { ApiKeyGenerator } from './apiKeyGenerator';
 export { PasswordUtils } from './passwordUtils';
 export { ResponseFormatter } from './responseFormatter';
+export { UriEncryption } from './uriEncryption';
 export { ValidationUtils } from './validation';
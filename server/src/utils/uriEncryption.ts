@@ -0,0 +1,68 @@
+/**
+ * Connection string encryption utility
+ * Mirrors the db_access service scheme (AES-GCM, random 12 byte nonce,
+ * base64 of nonce || ciphertext || tag) so MongoDB URIs can be sent as
+ * `mongo_uri_enc` instead of cleartext.
+ */
+
+import { createCipheriv, createDecipheriv, randomBytes } from 'crypto';
+
+const NONCE_LENGTH = 12;
+const TAG_LENGTH = 16;
+
+export class UriEncryption {
+  /**
+   * Hex encoded values are decoded, anything else is used as raw bytes
+   */
+  private static decodeKeyMaterial(value: string): Buffer {
+    if (value.length > 0 && value.length % 2 === 0 && /^[0-9a-fA-F]+$/.test(value)) {
+      return Buffer.from(value, 'hex');
+    }
+    return Buffer.from(value, 'utf8');
+  }
+
+  private static getKey(): Buffer {
+    const rawKey = process.env.AES_KEY;
+    if (!rawKey) {
+      throw new Error('AES_KEY must be configured to encrypt connection strings');
+    }
+
+    const key = this.decodeKeyMaterial(rawKey);
+    if (![16, 24, 32].includes(key.length)) {
+      throw new Error('AES_KEY must be 16, 24 or 32 bytes (raw or hex encoded)');
+    }
+
+    return key;
+  }
+
+  /**
+   * Encrypt a MongoDB URI for the db_access service
+   */
+  static encrypt(plaintext: string): string {
+    const key = this.getKey();
+    const nonce = randomBytes(NONCE_LENGTH);
+    const cipher = createCipheriv(`aes-${key.length * 8}-gcm`, key, nonce);
+    const ciphertext = Buffer.concat([cipher.update(plaintext, 'utf8'), cipher.final()]);
+    return Buffer.concat([nonce, ciphertext, cipher.getAuthTag()]).toString('base64');
+  }
+
+  /**
+   * Decrypt a value produced by encrypt()
+   */
+  static decrypt(encoded: string): string {
+    const key = this.getKey();
+    const sealed = Buffer.from(encoded, 'base64');
+    if (sealed.length < NONCE_LENGTH + TAG_LENGTH) {
+      throw new Error('invalid encrypted value');
+    }
+
+    const nonce = sealed.subarray(0, NONCE_LENGTH);
+    const tag = sealed.subarray(sealed.length - TAG_LENGTH);
+    const decipher = createDecipheriv(`aes-${key.length * 8}-gcm`, key, nonce);
+    decipher.setAuthTag(tag);
+    return Buffer.concat([
+      decipher.update(sealed.subarray(NONCE_LENGTH, sealed.length - TAG_LENGTH)),
+      decipher.final(),
+    ]).toString('utf8');
+  }
+}
@@ -0,0 +1,10 @@
+package configs
+
+// Build metadata, set at link time:
+//
+//	go build -ldflags "-X github.com/abhidhanve/universal-dashboard/services/db_access/configs.Version=v1.2.0"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
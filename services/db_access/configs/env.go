@@ -1,7 +1,10 @@
 package configs
 
 import (
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -10,15 +13,60 @@ var Env *env
 
 func InitEnvConfigs() {
 	Env = loadEnvVariables()
+
+	// Fail fast on values that would otherwise surface as odd runtime errors
+	if err := Env.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 }
 
 type env struct {
-	Port              string `mapstructure:"PORT"`
-	AES_key           string `mapstructure:"AES_KEY"`
-	AES_iv            string `mapstructure:"AES_IV"`
-	MaxConnections    int    `mapstructure:"MAX_CONNECTIONS"`
-	ConnectionTimeout string `mapstructure:"CONNECTION_TIMEOUT"`
-	LogLevel          string `mapstructure:"LOG_LEVEL"`
+	Port              string        `mapstructure:"PORT"`
+	AES_key           string        `mapstructure:"AES_KEY"`
+	TokenSecret       string        `mapstructure:"TOKEN_SECRET"`       // HMAC key for pagination cursors and confirm tokens
+	MaxConnections    int           `mapstructure:"MAX_CONNECTIONS"`    // Driver pool size per MongoDB client
+	ConnectionTimeout time.Duration `mapstructure:"CONNECTION_TIMEOUT"` // Connect and server selection timeout
+	LogLevel          string        `mapstructure:"LOG_LEVEL"`
+
+	// Operation context timeouts (see models.ContextConfig)
+	ShortTimeout  time.Duration `mapstructure:"SHORT_TIMEOUT"`
+	MediumTimeout time.Duration `mapstructure:"MEDIUM_TIMEOUT"`
+	LongTimeout   time.Duration `mapstructure:"LONG_TIMEOUT"`
+
+	// Cached external MongoDB clients (Method 3)
+	MaxCachedClients  int           `mapstructure:"MAX_CACHED_CLIENTS"`
+	ClientIdleTimeout time.Duration `mapstructure:"CLIENT_IDLE_TIMEOUT"`
+
+	// Lifetime of handles issued by POST /connections
+	ConnectionHandleTTL time.Duration `mapstructure:"CONNECTION_HANDLE_TTL"`
+
+	// Outbound host policy for user-supplied MongoDB URIs (comma separated lists)
+	MongoAllowedHosts         []string `mapstructure:"MONGO_ALLOWED_HOSTS"`
+	MongoDeniedHosts          []string `mapstructure:"MONGO_DENIED_HOSTS"`
+	MongoAllowedCIDRs         []string `mapstructure:"MONGO_ALLOWED_CIDRS"`
+	MongoDeniedCIDRs          []string `mapstructure:"MONGO_DENIED_CIDRS"`
+	MongoBlockPrivateNetworks bool     `mapstructure:"MONGO_BLOCK_PRIVATE_NETWORKS"`
+
+	// Default consistency settings, empty keeps the driver default
+	DefaultReadPreference string `mapstructure:"DEFAULT_READ_PREFERENCE"`
+	DefaultReadConcern    string `mapstructure:"DEFAULT_READ_CONCERN"`
+	DefaultWriteConcern   string `mapstructure:"DEFAULT_WRITE_CONCERN"`
+	SchemaReadPreference  string `mapstructure:"SCHEMA_READ_PREFERENCE"`
+
+	// Bulk update and delete by filter: most documents one call may touch, and
+	// how long a dry-run confirm token stays valid
+	BulkWriteLimit int           `mapstructure:"BULK_WRITE_LIMIT"`
+	BulkConfirmTTL time.Duration `mapstructure:"BULK_CONFIRM_TTL"`
+
+	// Document ETags: taken from DocumentVersionField when set, otherwise a
+	// hash of the document. DocumentAutoVersion maintains a _v counter on writes.
+	DocumentVersionField string `mapstructure:"DOCUMENT_VERSION_FIELD"`
+	DocumentAutoVersion  bool   `mapstructure:"DOCUMENT_AUTO_VERSION"`
+
+	// Graceful shutdown: readiness is reported as failing for ShutdownDrainDelay
+	// before the listener closes, then in-flight requests get ShutdownTimeout
+	ShutdownDrainDelay time.Duration `mapstructure:"SHUTDOWN_DRAIN_DELAY"`
+	ShutdownTimeout    time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
 }
 
 func loadEnvVariables() (config *env) {
@@ -32,6 +80,36 @@ func loadEnvVariables() (config *env) {
 	// Also read from environment variables (this will override file values)
 	viper.AutomaticEnv()
 
+	// Defaults register the keys with viper so Unmarshal picks up env overrides
+	// even when no config file is present
+	viper.SetDefault("PORT", "9081")
+	viper.SetDefault("AES_KEY", "")
+	viper.SetDefault("TOKEN_SECRET", "")
+	viper.SetDefault("MAX_CONNECTIONS", 100)
+	viper.SetDefault("CONNECTION_TIMEOUT", "30s")
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("SHORT_TIMEOUT", "10s")
+	viper.SetDefault("MEDIUM_TIMEOUT", "30s")
+	viper.SetDefault("LONG_TIMEOUT", "60s")
+	viper.SetDefault("MAX_CACHED_CLIENTS", 50)
+	viper.SetDefault("CLIENT_IDLE_TIMEOUT", "10m")
+	viper.SetDefault("CONNECTION_HANDLE_TTL", "30m")
+	viper.SetDefault("MONGO_ALLOWED_HOSTS", []string{})
+	viper.SetDefault("MONGO_DENIED_HOSTS", []string{})
+	viper.SetDefault("MONGO_ALLOWED_CIDRS", []string{})
+	viper.SetDefault("MONGO_DENIED_CIDRS", []string{})
+	viper.SetDefault("MONGO_BLOCK_PRIVATE_NETWORKS", true)
+	viper.SetDefault("DEFAULT_READ_PREFERENCE", "")
+	viper.SetDefault("DEFAULT_READ_CONCERN", "")
+	viper.SetDefault("DEFAULT_WRITE_CONCERN", "")
+	viper.SetDefault("SCHEMA_READ_PREFERENCE", "secondaryPreferred")
+	viper.SetDefault("BULK_WRITE_LIMIT", 1000)
+	viper.SetDefault("BULK_CONFIRM_TTL", "5m")
+	viper.SetDefault("DOCUMENT_VERSION_FIELD", "")
+	viper.SetDefault("DOCUMENT_AUTO_VERSION", false)
+	viper.SetDefault("SHUTDOWN_DRAIN_DELAY", "5s")
+	viper.SetDefault("SHUTDOWN_TIMEOUT", "20s")
+
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("Warning: Config file not found, using environment variables: %v", err)
 		// Don't fatal error in production, use env vars
@@ -43,3 +121,41 @@ func loadEnvVariables() (config *env) {
 
 	return
 }
+
+// Validate checks numeric and duration settings for sane values
+func (e *env) Validate() error {
+	if e.MaxConnections < 1 || e.MaxConnections > 10000 {
+		return fmt.Errorf("MAX_CONNECTIONS must be between 1 and 10000, got %d", e.MaxConnections)
+	}
+	if e.ConnectionTimeout <= 0 {
+		return fmt.Errorf("CONNECTION_TIMEOUT must be a positive duration, got %s", e.ConnectionTimeout)
+	}
+	if e.ShortTimeout <= 0 || e.MediumTimeout <= 0 || e.LongTimeout <= 0 {
+		return fmt.Errorf("SHORT_TIMEOUT, MEDIUM_TIMEOUT and LONG_TIMEOUT must be positive durations")
+	}
+	if e.ShortTimeout > e.MediumTimeout || e.MediumTimeout > e.LongTimeout {
+		return fmt.Errorf("timeouts must satisfy SHORT_TIMEOUT <= MEDIUM_TIMEOUT <= LONG_TIMEOUT, got %s/%s/%s", e.ShortTimeout, e.MediumTimeout, e.LongTimeout)
+	}
+	if e.MaxCachedClients < 1 {
+		return fmt.Errorf("MAX_CACHED_CLIENTS must be at least 1, got %d", e.MaxCachedClients)
+	}
+	if e.ClientIdleTimeout <= 0 || e.ConnectionHandleTTL <= 0 {
+		return fmt.Errorf("CLIENT_IDLE_TIMEOUT and CONNECTION_HANDLE_TTL must be positive durations")
+	}
+	if e.BulkWriteLimit < 1 {
+		return fmt.Errorf("BULK_WRITE_LIMIT must be at least 1, got %d", e.BulkWriteLimit)
+	}
+	if e.BulkConfirmTTL <= 0 {
+		return fmt.Errorf("BULK_CONFIRM_TTL must be a positive duration, got %s", e.BulkConfirmTTL)
+	}
+	if e.DocumentVersionField == "_id" || strings.HasPrefix(e.DocumentVersionField, "$") {
+		return fmt.Errorf("DOCUMENT_VERSION_FIELD must be a plain field name, got %q", e.DocumentVersionField)
+	}
+	if e.DocumentAutoVersion && e.DocumentVersionField != "" && e.DocumentVersionField != "_v" {
+		return fmt.Errorf("DOCUMENT_AUTO_VERSION maintains _v, DOCUMENT_VERSION_FIELD must be empty or _v, got %q", e.DocumentVersionField)
+	}
+	if e.ShutdownDrainDelay < 0 || e.ShutdownTimeout <= 0 {
+		return fmt.Errorf("SHUTDOWN_DRAIN_DELAY must not be negative and SHUTDOWN_TIMEOUT must be positive")
+	}
+	return nil
+}
@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/abhidhanve/universal-dashboard/services/db_access/configs"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/middleware"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/mongodb"
 	"github.com/abhidhanve/universal-dashboard/services/db_access/src/routes"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/services"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +24,40 @@ func main() {
 	// Initialize environment configurations
 	configs.InitEnvConfigs()
 
+	// Driver pool size, connect timeout and per-operation timeouts
+	mongodb.ConfigureConnectionSettings(uint64(configs.Env.MaxConnections), configs.Env.ConnectionTimeout)
+	models.DefaultContextConfig = models.ContextConfig{
+		ShortTimeout:  configs.Env.ShortTimeout,
+		MediumTimeout: configs.Env.MediumTimeout,
+		LongTimeout:   configs.Env.LongTimeout,
+	}
+
+	// Configure the pooled client registry used by Method 3 operations
+	mongodb.ConfigureClientRegistry(configs.Env.MaxCachedClients, configs.Env.ClientIdleTimeout)
+
+	// Restrict which hosts user-supplied MongoDB URIs may reach
+	hostPolicy, err := mongodb.NewHostPolicy(
+		configs.Env.MongoAllowedHosts,
+		configs.Env.MongoDeniedHosts,
+		configs.Env.MongoAllowedCIDRs,
+		configs.Env.MongoDeniedCIDRs,
+		configs.Env.MongoBlockPrivateNetworks,
+	)
+	if err != nil {
+		log.Fatalf("Invalid MongoDB host policy: %v", err)
+	}
+	mongodb.ConfigureHostPolicy(hostPolicy)
+
+	// Default read preference, read concern and write concern
+	if err := mongodb.ConfigureConsistencyDefaults(mongodb.ConsistencyDefaults{
+		ReadPreference:       configs.Env.DefaultReadPreference,
+		ReadConcern:          configs.Env.DefaultReadConcern,
+		WriteConcern:         configs.Env.DefaultWriteConcern,
+		SchemaReadPreference: configs.Env.SchemaReadPreference,
+	}); err != nil {
+		log.Fatalf("Invalid consistency defaults: %v", err)
+	}
+
 	// Get port from environment (fallback to 9081 if not set)
 	port := configs.Env.Port
 	if port == "" {
@@ -26,27 +70,78 @@ func main() {
 	// Configure CORS
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:5173", "http://localhost:3000", "http://localhost:8081"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Connection-ID", "X-Mongo-URI-Enc", "If-Match", "X-Extended-JSON", middleware.RequestTimeoutHeader}
+	config.ExposeHeaders = []string{"ETag"}
 	router.Use(cors.New(config))
+	router.Use(middleware.RequestTimeout())
 
 	routes.SetupRoutes(router)
 
 	fmt.Printf("🚀 DB Access Service (MVC Architecture) starting on port %s\n", port)
 	fmt.Println("📋 Available endpoints:")
-	fmt.Println("   • Health: GET /ping")
+	fmt.Println("   • Health: GET /ping, GET /healthz, GET /readyz")
 	fmt.Println("   • Allocate DB: POST /allocate")
+	fmt.Println("   • Connections: POST /connections, DELETE /connections/:id")
 	fmt.Println("   • Collections: GET /collections/:db")
 	fmt.Println("   • Schema Detection: GET /detect-schema/:db/:collection")
 	fmt.Println("   • Documents: GET /entries/:db/:collection")
-	fmt.Println("   • CRUD: POST|GET|PUT|DELETE /entry/:db/:collection[/:id]")
+	fmt.Println("   • Bulk Insert: POST /entries/:db/:collection")
+	fmt.Println("   • Bulk Update/Delete: POST /entries/:db/:collection/{update,delete}-many")
+	fmt.Println("   • Search: GET /search/:db/:collection?q=")
+	fmt.Println("   • Aggregate: POST /aggregate/:db/:collection")
+	fmt.Println("   • Distinct Values: GET /distinct/:db/:collection?fields=")
+	fmt.Println("   • Count: GET /count/:db/:collection")
+	fmt.Println("   • CRUD: POST|GET|PUT|PATCH|DELETE /entry/:db/:collection[/:id]")
 	fmt.Println("   • API v1: /api/v1/*")
 	fmt.Println("   • Info: GET /")
 
 	// Start the server (bind to all interfaces for Railway)
 	serverAddr := fmt.Sprintf("0.0.0.0:%s", port)
+	// WriteTimeout stays unset: NDJSON streams run for as long as the client
+	// reads, bounded by the per-batch idle timeout instead
+	server := &http.Server{
+		Addr:              serverAddr,
+		Handler:           router,
+		ReadHeaderTimeout: configs.Env.ShortTimeout,
+		IdleTimeout:       configs.Env.LongTimeout,
+	}
+
 	fmt.Printf("🚀 Starting server on %s\n", serverAddr)
-	if err := router.Run(serverAddr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM (Render/Railway send SIGTERM on deploy)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	stop()
+
+	shutdown(server)
+}
+
+// shutdown flips readiness, drains in-flight requests and disconnects cached
+// MongoDB clients
+func shutdown(server *http.Server) {
+	log.Println("Shutdown signal received, draining requests")
+
+	// Report not ready first so the orchestrator stops routing new traffic
+	services.SetReady(false)
+	time.Sleep(configs.Env.ShutdownDrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), configs.Env.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server did not drain cleanly: %v", err)
+	}
+
+	if err := mongodb.CloseAllClients(ctx); err != nil {
+		log.Printf("Failed to disconnect MongoDB clients: %v", err)
 	}
+
+	log.Println("Shutdown complete")
 }
@@ -29,9 +29,9 @@ func (ctrl *CollectionController) ListCollections(c *gin.Context) {
 	}
 
 	// Call service layer
-	response, err := ctrl.collectionService.ListCollections(dbName)
+	response, err := ctrl.collectionService.ListCollections(c.Request.Context(), connectionRefFromRequest(c), dbName, c.Query("estimated") == "true")
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -54,9 +54,9 @@ func (ctrl *CollectionController) DetectSchema(c *gin.Context) {
 	}
 
 	// Call service layer
-	response, err := ctrl.collectionService.DetectSchema(dbName, collectionName)
+	response, err := ctrl.collectionService.DetectSchema(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -78,14 +78,25 @@ func (ctrl *CollectionController) GetSampleData(c *gin.Context) {
 		return
 	}
 
+	mode, err := extJSONModeFromRequest(c)
+	if err != nil {
+		utils.SendBadRequest(c, err.Error())
+		return
+	}
+
 	// Parse limit parameter
 	limitStr := c.DefaultQuery("limit", "10")
 	limit := utils.ValidateLimit(limitStr, 10, 100)
 
 	// Call service layer
-	response, err := ctrl.collectionService.GetSampleData(dbName, collectionName, limit)
+	response, err := ctrl.collectionService.GetSampleData(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, limit)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := mode.EncodeDocuments(response.Data); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -101,10 +112,15 @@ func (ctrl *CollectionController) AnalyzeDocuments(c *gin.Context) {
 		return
 	}
 
+	// The connection may come in the body or, like other path-based routes, in headers
+	if req.ConnectionRef == (models.ConnectionRef{}) {
+		req.ConnectionRef = connectionRefFromRequest(c)
+	}
+
 	// Call service layer
-	response, err := ctrl.collectionService.AnalyzeMultipleCollections(req)
+	response, err := ctrl.collectionService.AnalyzeMultipleCollections(c.Request.Context(), req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -121,9 +137,9 @@ func (ctrl *CollectionController) Method3SchemaAnalysis(c *gin.Context) {
 	}
 
 	// Call service layer for Method 3 schema analysis
-	response, err := ctrl.collectionService.Method3DetectSchema(req)
+	response, err := ctrl.collectionService.Method3DetectSchema(c.Request.Context(), req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -134,15 +150,58 @@ func (ctrl *CollectionController) Method3SchemaAnalysis(c *gin.Context) {
 func (ctrl *CollectionController) Method3DataInsert(c *gin.Context) {
 	var req models.Method3DataInsertRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, err.Error())
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
 		return
 	}
+	if mode.Enabled() {
+		var err error
+		if req.Data, err = extJSONDocument(c, "data"); err != nil {
+			utils.SendValidationError(c, err.Error())
+			return
+		}
+	}
 
 	// Call service layer for Method 3 data insertion
-	response, err := ctrl.collectionService.Method3InsertData(req)
+	response, err := ctrl.collectionService.Method3InsertData(c.Request.Context(), req)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	if response.DocumentID, err = mode.EncodeValue(response.DocumentID); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Method3DataInsertMany handles bulk data insertion using external MongoDB URI (Method 3)
+func (ctrl *CollectionController) Method3DataInsertMany(c *gin.Context) {
+	var req models.Method3BulkInsertRequest
+
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
+		return
+	}
+	if mode.Enabled() {
+		var err error
+		if req.Documents, err = extJSONDocuments(c, "documents"); err != nil {
+			utils.SendValidationError(c, err.Error())
+			return
+		}
+	}
+
+	// Call service layer for Method 3 bulk insertion
+	response, err := ctrl.collectionService.Method3BulkInsertData(c.Request.Context(), req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := encodeInsertedIDs(mode, response); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -153,15 +212,28 @@ func (ctrl *CollectionController) Method3DataInsert(c *gin.Context) {
 func (ctrl *CollectionController) Method3DataGet(c *gin.Context) {
 	var req models.Method3DataRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, err.Error())
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	// Stream every matching document when asked for NDJSON
+	if wantsNDJSON(c) {
+		streamNDJSON(c, mode, func(emit services.DocumentEmitter) error {
+			return ctrl.collectionService.Method3StreamData(c.Request.Context(), req, emit)
+		})
 		return
 	}
 
 	// Call service layer for Method 3 data retrieval
-	response, err := ctrl.collectionService.Method3GetData(req)
+	response, err := ctrl.collectionService.Method3GetData(c.Request.Context(), req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := mode.EncodeDocuments(response.Data); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -178,9 +250,9 @@ func (ctrl *CollectionController) Method3DataDelete(c *gin.Context) {
 	}
 
 	// Call service layer for Method 3 data deletion
-	response, err := ctrl.collectionService.Method3DeleteData(req)
+	response, err := ctrl.collectionService.Method3DeleteData(c.Request.Context(), req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -197,9 +269,9 @@ func (ctrl *CollectionController) Method3AddSchemaFields(c *gin.Context) {
 	}
 
 	// Call service layer for Method 3 schema field addition
-	response, err := ctrl.collectionService.Method3AddSchemaFields(req)
+	response, err := ctrl.collectionService.Method3AddSchemaFields(c.Request.Context(), req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -216,9 +288,9 @@ func (ctrl *CollectionController) Method3RemoveSchemaField(c *gin.Context) {
 	}
 
 	// Call service layer for Method 3 schema field removal
-	response, err := ctrl.collectionService.Method3RemoveSchemaField(req)
+	response, err := ctrl.collectionService.Method3RemoveSchemaField(c.Request.Context(), req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/services"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ConnectionController struct {
+	connectionService *services.ConnectionService
+}
+
+func NewConnectionController() *ConnectionController {
+	return &ConnectionController{
+		connectionService: services.NewConnectionService(),
+	}
+}
+
+// CreateConnection handles registering a MongoDB URI and returns an opaque handle
+func (ctrl *ConnectionController) CreateConnection(c *gin.Context) {
+	var req models.CreateConnectionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendValidationError(c, err.Error())
+		return
+	}
+
+	// Call service layer
+	response, err := ctrl.connectionService.CreateConnection(c.Request.Context(), req)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// DeleteConnection handles releasing a connection handle
+func (ctrl *ConnectionController) DeleteConnection(c *gin.Context) {
+	connectionID := c.Param("id")
+
+	if connectionID == "" {
+		utils.SendBadRequest(c, "Connection ID is required")
+		return
+	}
+
+	// Call service layer
+	if err := ctrl.connectionService.DeleteConnection(connectionID); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, "Connection released successfully", gin.H{"connection_id": connectionID})
+}
@@ -34,9 +34,9 @@ func (ctrl *DatabaseController) AllocateDatabase(c *gin.Context) {
 	}
 
 	// Call service layer
-	response, err := ctrl.databaseService.AllocateDatabase(req)
+	response, err := ctrl.databaseService.AllocateDatabase(c.Request.Context(), req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -53,9 +53,9 @@ func (ctrl *DatabaseController) GetDatabaseInfo(c *gin.Context) {
 	}
 
 	// Call service layer
-	info, err := ctrl.databaseService.GetDatabaseInfo(dbName)
+	info, err := ctrl.databaseService.GetDatabaseInfo(c.Request.Context(), connectionRefFromRequest(c), dbName)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -72,9 +72,9 @@ func (ctrl *DatabaseController) TestConnection(c *gin.Context) {
 	}
 
 	// Call service layer
-	err := ctrl.databaseService.TestDatabaseConnection(dbName)
+	err := ctrl.databaseService.TestDatabaseConnection(c.Request.Context(), connectionRefFromRequest(c), dbName)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -35,21 +35,76 @@ func (ctrl *DocumentController) CreateEntry(c *gin.Context) {
 	}
 
 	var req models.CreateDocumentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, err.Error())
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
 		return
 	}
+	if mode.Enabled() {
+		var err error
+		if req.Data, err = extJSONDocument(c, "data"); err != nil {
+			utils.SendValidationError(c, err.Error())
+			return
+		}
+	}
 
 	// Call service layer
-	response, err := ctrl.documentService.CreateDocument(dbName, collectionName, req)
+	response, err := ctrl.documentService.CreateDocument(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, req)
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
+		return
+	}
+
+	if response.DocumentID, err = mode.EncodeValue(response.DocumentID); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, response)
 }
 
+// CreateEntries handles inserting many documents into a collection
+func (ctrl *DocumentController) CreateEntries(c *gin.Context) {
+	dbName := c.Param("db")
+	collectionName := c.Param("collection")
+
+	if dbName == "" {
+		utils.SendBadRequest(c, "Database name is required")
+		return
+	}
+
+	if collectionName == "" {
+		utils.SendBadRequest(c, "Collection name is required")
+		return
+	}
+
+	var req models.BulkInsertRequest
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
+		return
+	}
+	if mode.Enabled() {
+		var err error
+		if req.Documents, err = extJSONDocuments(c, "documents"); err != nil {
+			utils.SendValidationError(c, err.Error())
+			return
+		}
+	}
+
+	// Call service layer
+	response, err := ctrl.documentService.InsertDocuments(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, req)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := encodeInsertedIDs(mode, response); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetCollectionEntries handles retrieving all entries from a collection with pagination
 func (ctrl *DocumentController) GetCollectionEntries(c *gin.Context) {
 	dbName := c.Param("db")
@@ -65,13 +120,37 @@ func (ctrl *DocumentController) GetCollectionEntries(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	limit, skip := utils.ParsePaginationParams(c)
+	mode, err := extJSONModeFromRequest(c)
+	if err != nil {
+		utils.SendBadRequest(c, err.Error())
+		return
+	}
+
+	// Stream every matching document when asked for NDJSON
+	if wantsNDJSON(c) {
+		query := listQueryFromRequest(c)
+		limit, ok := streamLimit(c)
+		if !ok {
+			utils.SendBadRequest(c, "limit must be a non-negative integer")
+			return
+		}
+		query.Limit = limit
+
+		streamNDJSON(c, mode, func(emit services.DocumentEmitter) error {
+			return ctrl.documentService.StreamCollectionEntries(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, query, emit)
+		})
+		return
+	}
 
 	// Call service layer
-	response, err := ctrl.documentService.GetCollectionEntries(dbName, collectionName, limit, skip)
+	response, err := ctrl.documentService.GetCollectionEntries(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, listQueryFromRequest(c))
 	if err != nil {
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := mode.EncodeDocuments(response.Data); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -100,23 +179,91 @@ func (ctrl *DocumentController) UpdateEntry(c *gin.Context) {
 	}
 
 	var req models.UpdateDocumentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendValidationError(c, err.Error())
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
 		return
 	}
+	if mode.Enabled() {
+		var err error
+		if req.Data, err = extJSONDocument(c, "data"); err != nil {
+			utils.SendValidationError(c, err.Error())
+			return
+		}
+	}
 
 	// Call service layer
-	response, err := ctrl.documentService.UpdateDocument(dbName, collectionName, entryID, req)
+	response, err := ctrl.documentService.UpdateDocument(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, entryID, req, updateOptionsFromRequest(c))
 	if err != nil {
-		if err.Error() == "document not found" {
-			utils.SendNotFound(c, "Document not found")
-			return
-		}
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	if err := encodeUpdateResponse(mode, response); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(updateStatus(response), response)
+}
+
+// PatchEntry handles applying update operators to a specific document
+func (ctrl *DocumentController) PatchEntry(c *gin.Context) {
+	dbName := c.Param("db")
+	collectionName := c.Param("collection")
+	entryID := c.Param("id")
+
+	if dbName == "" {
+		utils.SendBadRequest(c, "Database name is required")
+		return
+	}
+
+	if collectionName == "" {
+		utils.SendBadRequest(c, "Collection name is required")
+		return
+	}
+
+	if entryID == "" {
+		utils.SendBadRequest(c, "Document ID is required")
+		return
+	}
+
+	var req models.PatchDocumentRequest
+	// The update itself is always parsed as Extended JSON
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	// Call service layer
+	response, err := ctrl.documentService.PatchDocument(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, entryID, req, updateOptionsFromRequest(c))
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := encodeUpdateResponse(mode, response); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	setETag(c, response.ETag)
+	c.JSON(updateStatus(response), response)
+}
+
+// updateStatus is 201 when an upsert created the document
+func updateStatus(response *models.UpdateDocumentResponse) int {
+	if response.UpsertedID != nil {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
+// setETag sends the document version clients pass back in If-Match
+func setETag(c *gin.Context, etag string) {
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
 }
 
 // DeleteEntry handles deleting a specific document
@@ -141,13 +288,9 @@ func (ctrl *DocumentController) DeleteEntry(c *gin.Context) {
 	}
 
 	// Call service layer
-	response, err := ctrl.documentService.DeleteDocument(dbName, collectionName, entryID)
+	response, err := ctrl.documentService.DeleteDocument(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, entryID, c.GetHeader("If-Match"))
 	if err != nil {
-		if err.Error() == "document not found" {
-			utils.SendNotFound(c, "Document not found")
-			return
-		}
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
 		return
 	}
 
@@ -175,14 +318,21 @@ func (ctrl *DocumentController) GetEntry(c *gin.Context) {
 		return
 	}
 
+	mode, err := extJSONModeFromRequest(c)
+	if err != nil {
+		utils.SendBadRequest(c, err.Error())
+		return
+	}
+
 	// Call service layer
-	document, err := ctrl.documentService.GetDocumentByID(dbName, collectionName, entryID)
+	document, etag, err := ctrl.documentService.GetDocumentByID(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, entryID)
 	if err != nil {
-		if err.Error() == "document not found" {
-			utils.SendNotFound(c, "Document not found")
-			return
-		}
-		utils.SendInternalError(c, err.Error())
+		sendServiceError(c, err)
+		return
+	}
+
+	if document, err = mode.EncodeDocument(document); err != nil {
+		sendServiceError(c, err)
 		return
 	}
 
@@ -191,7 +341,83 @@ func (ctrl *DocumentController) GetEntry(c *gin.Context) {
 		"collection":  collectionName,
 		"document_id": entryID,
 		"data":        document,
+		"etag":        etag,
 	}
 
+	setETag(c, etag)
 	utils.SendSuccessResponse(c, http.StatusOK, "Document retrieved successfully", data)
 }
+
+// UpdateEntries handles updating many documents by filter, previewing first and running once the
+// confirm token is echoed back
+func (ctrl *DocumentController) UpdateEntries(c *gin.Context) {
+	dbName := c.Param("db")
+	collectionName := c.Param("collection")
+
+	if dbName == "" {
+		utils.SendBadRequest(c, "Database name is required")
+		return
+	}
+
+	if collectionName == "" {
+		utils.SendBadRequest(c, "Collection name is required")
+		return
+	}
+
+	var req models.BulkWriteRequest
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	// Call service layer
+	response, err := ctrl.documentService.UpdateMany(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, req)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := encodeExtJSONValues(mode, response.SampleIDs); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteEntries handles deleting many documents by filter, previewing first and running once the
+// confirm token is echoed back
+func (ctrl *DocumentController) DeleteEntries(c *gin.Context) {
+	dbName := c.Param("db")
+	collectionName := c.Param("collection")
+
+	if dbName == "" {
+		utils.SendBadRequest(c, "Database name is required")
+		return
+	}
+
+	if collectionName == "" {
+		utils.SendBadRequest(c, "Collection name is required")
+		return
+	}
+
+	var req models.BulkWriteRequest
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	// Call service layer
+	response, err := ctrl.documentService.DeleteMany(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, req)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := encodeExtJSONValues(mode, response.SampleIDs); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ExtendedJSONHeader opts a request into MongoDB Extended JSON documents;
+// the extended_json query parameter does the same
+const ExtendedJSONHeader = "X-Extended-JSON"
+
+// extJSONModeFromRequest reads the Extended JSON mode, header first
+func extJSONModeFromRequest(c *gin.Context) (utils.ExtJSONMode, error) {
+	value := c.GetHeader(ExtendedJSONHeader)
+	if value == "" {
+		value = c.Query("extended_json")
+	}
+	return utils.ParseExtJSONMode(value)
+}
+
+// bindDocumentRequest binds the JSON body into req. The body is kept so that
+// in Extended JSON mode the documents in it can be decoded again with their
+// BSON types.
+func bindDocumentRequest(c *gin.Context, req interface{}) (utils.ExtJSONMode, bool) {
+	mode, err := extJSONModeFromRequest(c)
+	if err != nil {
+		utils.SendBadRequest(c, err.Error())
+		return mode, false
+	}
+
+	if err := c.ShouldBindBodyWith(req, binding.JSON); err != nil {
+		utils.SendValidationError(c, err.Error())
+		return mode, false
+	}
+	return mode, true
+}
+
+// rawBodyField returns one top-level field of a body bound with
+// bindDocumentRequest
+func rawBodyField(c *gin.Context, key string) (json.RawMessage, error) {
+	body, ok := c.Get(gin.BodyBytesKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: request body was not read", utils.ErrInvalidExtJSON)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body.([]byte), &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", utils.ErrInvalidExtJSON, err)
+	}
+	return fields[key], nil
+}
+
+// extJSONDocument decodes the Extended JSON document stored under key
+func extJSONDocument(c *gin.Context, key string) (map[string]interface{}, error) {
+	raw, err := rawBodyField(c, key)
+	if err != nil {
+		return nil, err
+	}
+	return utils.DecodeExtJSONDocument(raw)
+}
+
+// extJSONDocuments decodes the array of Extended JSON documents stored under key
+func extJSONDocuments(c *gin.Context, key string) ([]map[string]interface{}, error) {
+	raw, err := rawBodyField(c, key)
+	if err != nil {
+		return nil, err
+	}
+	return utils.DecodeExtJSONDocuments(raw)
+}
+
+// encodeExtJSONValues converts values such as document IDs in place
+func encodeExtJSONValues(mode utils.ExtJSONMode, values []interface{}) error {
+	for i, value := range values {
+		encoded, err := mode.EncodeValue(value)
+		if err != nil {
+			return err
+		}
+		values[i] = encoded
+	}
+	return nil
+}
+
+// encodeInsertedIDs converts the IDs reported by a bulk insert
+func encodeInsertedIDs(mode utils.ExtJSONMode, response *models.BulkInsertResponse) error {
+	for i := range response.Results {
+		encoded, err := mode.EncodeValue(response.Results[i].InsertedID)
+		if err != nil {
+			return err
+		}
+		response.Results[i].InsertedID = encoded
+	}
+	return nil
+}
+
+// encodeUpdateResponse converts the upserted ID and returned document of a
+// single document update
+func encodeUpdateResponse(mode utils.ExtJSONMode, response *models.UpdateDocumentResponse) error {
+	upsertedID, err := mode.EncodeValue(response.UpsertedID)
+	if err != nil {
+		return err
+	}
+	document, err := mode.EncodeDocument(response.Document)
+	if err != nil {
+		return err
+	}
+	response.UpsertedID = upsertedID
+	response.Document = document
+	return nil
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/services"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	healthService *services.HealthService
+}
+
+func NewHealthController() *HealthController {
+	return &HealthController{
+		healthService: services.NewHealthService(),
+	}
+}
+
+// Live handles the liveness probe; it only fails if the process cannot serve HTTP
+func (ctrl *HealthController) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.healthService.Liveness())
+}
+
+// Ready handles the readiness probe; it reports 503 while shutting down or
+// when the configuration is invalid
+func (ctrl *HealthController) Ready(c *gin.Context) {
+	response, isReady := ctrl.healthService.Readiness()
+	if !isReady {
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
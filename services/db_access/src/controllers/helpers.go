@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/mongodb"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/services"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Headers that identify the external MongoDB connection on path-based routes.
+// Raw URIs are deliberately not accepted in headers since headers get logged.
+const (
+	ConnectionIDHeader = "X-Connection-ID"
+	MongoURIEncHeader  = "X-Mongo-URI-Enc"
+)
+
+// connectionRefFromRequest builds a connection reference from request headers.
+// Handles are bearer credentials, so they are never read from the URL, which
+// ends up in access logs and browser history.
+func connectionRefFromRequest(c *gin.Context) models.ConnectionRef {
+	return models.ConnectionRef{
+		ConnectionID: c.GetHeader(ConnectionIDHeader),
+		MongoURIEnc:  c.GetHeader(MongoURIEncHeader),
+	}
+}
+
+// consistencyFromQuery reads read_preference, read_concern and write_concern
+// query parameters; validation happens in the service layer
+func consistencyFromQuery(c *gin.Context) models.ConsistencyOptions {
+	return models.ConsistencyOptions{
+		ReadPreference: c.Query("read_preference"),
+		ReadConcern:    c.Query("read_concern"),
+		WriteConcern:   c.Query("write_concern"),
+	}
+}
+
+// listQueryFromRequest reads pagination, filter, sort and projection
+// parameters shared by the listing endpoints
+func listQueryFromRequest(c *gin.Context) models.QueryParams {
+	limit, skip := utils.ParsePaginationParams(c)
+	return models.QueryParams{
+		Limit:   limit,
+		Skip:    skip,
+		Filter:  c.Query("filter"),
+		Sort:    c.Query("sort"),
+		Fields:  c.Query("fields"),
+		Cursor:  c.Query("cursor"),
+		Count:   c.Query("count"),
+		Explain: c.Query("explain") == "true",
+	}
+}
+
+// updateOptionsFromRequest reads the upsert, replace and return_document
+// flags and the If-Match header of single document updates
+func updateOptionsFromRequest(c *gin.Context) models.UpdateOptions {
+	return models.UpdateOptions{
+		Upsert:         c.Query("upsert") == "true",
+		Replace:        c.Query("replace") == "true",
+		ReturnDocument: c.Query("return_document") == "true",
+		IfMatch:        c.GetHeader("If-Match"),
+	}
+}
+
+// sendServiceError maps known service errors to structured responses and
+// falls back to a 500 for everything else
+func sendServiceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrConnectionRequired), errors.Is(err, utils.ErrInvalidEncryptedValue):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeConnectionRequired)
+	case errors.Is(err, utils.ErrEncryptionNotConfigured):
+		utils.SendErrorResponse(c, http.StatusServiceUnavailable, err.Error(), models.ErrorCodeGeneric)
+	case errors.Is(err, mongodb.ErrHostNotAllowed):
+		utils.SendErrorResponse(c, http.StatusForbidden, err.Error(), models.ErrorCodeHostNotAllowed)
+	case errors.Is(err, mongodb.ErrInvalidTLSOptions):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeInvalidTLSOptions)
+	case errors.Is(err, mongodb.ErrTLSHandshake):
+		utils.SendErrorResponse(c, http.StatusBadGateway, err.Error(), models.ErrorCodeTLSHandshake)
+	case errors.Is(err, mongodb.ErrInvalidConsistencyOption):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeInvalidOption)
+	case errors.Is(err, mongodb.ErrConnectionNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, err.Error(), models.ErrorCodeConnectionNotFound)
+	case errors.Is(err, services.ErrInvalidDatabaseName), errors.Is(err, services.ErrInvalidCollectionName):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeInvalidName)
+	case errors.Is(err, services.ErrDocumentNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, err.Error(), models.ErrorCodeDocumentNotFound)
+	case errors.Is(err, utils.ErrInvalidFilter):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeInvalidFilter)
+	case errors.Is(err, utils.ErrInvalidPipeline):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeInvalidPipeline)
+	case errors.Is(err, utils.ErrInvalidUpdate):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeInvalidUpdate)
+	case errors.Is(err, services.ErrInvalidConfirmation):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error(), models.ErrorCodeConfirmation)
+	case errors.Is(err, services.ErrPreconditionFailed):
+		utils.SendErrorResponse(c, http.StatusPreconditionFailed, err.Error(), models.ErrorCodePreconditionFailed)
+	case errors.Is(err, services.ErrWriteLimitExceeded):
+		utils.SendErrorResponse(c, http.StatusUnprocessableEntity, err.Error(), models.ErrorCodeWriteLimit)
+	case errors.Is(err, utils.ErrInvalidSort), errors.Is(err, utils.ErrInvalidProjection), errors.Is(err, services.ErrInvalidCursor), errors.Is(err, services.ErrInvalidPagination), errors.Is(err, services.ErrInvalidQuery):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeInvalidOption)
+	case errors.Is(err, services.ErrNotSupported):
+		utils.SendErrorResponse(c, http.StatusNotImplemented, err.Error(), models.ErrorCodeNotSupported)
+	case errors.Is(err, mongodb.ErrTooManyClients):
+		utils.SendErrorResponse(c, http.StatusServiceUnavailable, err.Error(), models.ErrorCodeGeneric)
+	case errors.Is(err, context.DeadlineExceeded), mongo.IsTimeout(err):
+		utils.SendErrorResponse(c, http.StatusGatewayTimeout, err.Error(), models.ErrorCodeTimeout)
+	default:
+		utils.SendInternalError(c, err.Error())
+	}
+}
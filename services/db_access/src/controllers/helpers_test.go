@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/mongodb"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/services"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// testContext returns a gin context for a GET of target with the given headers
+func testContext(target string, headers map[string]string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	for name, value := range headers {
+		c.Request.Header.Set(name, value)
+	}
+	return c, w
+}
+
+func TestConnectionRefFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    models.ConnectionRef
+	}{
+		{"nothing", "/entries/db/coll", nil, models.ConnectionRef{}},
+		{"header", "/entries/db/coll", map[string]string{ConnectionIDHeader: "abc"}, models.ConnectionRef{ConnectionID: "abc"}},
+		{"query ignored", "/entries/db/coll?connection_id=abc", nil, models.ConnectionRef{}},
+		{"header only", "/entries/db/coll?connection_id=query", map[string]string{ConnectionIDHeader: "header"}, models.ConnectionRef{ConnectionID: "header"}},
+		{"encrypted URI header", "/entries/db/coll", map[string]string{MongoURIEncHeader: "c2VjcmV0"}, models.ConnectionRef{MongoURIEnc: "c2VjcmV0"}},
+		{"raw URI query ignored", "/entries/db/coll?mongo_uri=mongodb://localhost", nil, models.ConnectionRef{}},
+		{"raw URI header ignored", "/entries/db/coll", map[string]string{"X-Mongo-URI": "mongodb://localhost"}, models.ConnectionRef{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := testContext(tt.target, tt.headers)
+			if got := connectionRefFromRequest(c); got != tt.want {
+				t.Errorf("connectionRefFromRequest = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendServiceError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   int
+	}{
+		{"no connection", services.ErrConnectionRequired, http.StatusBadRequest, models.ErrorCodeConnectionRequired},
+		{"unknown handle", fmt.Errorf("wrapped: %w", mongodb.ErrConnectionNotFound), http.StatusNotFound, models.ErrorCodeConnectionNotFound},
+		{"bad encrypted URI", fmt.Errorf("failed to decrypt mongo_uri_enc: %w", utils.ErrInvalidEncryptedValue), http.StatusBadRequest, models.ErrorCodeConnectionRequired},
+		{"encryption not configured", utils.ErrEncryptionNotConfigured, http.StatusServiceUnavailable, models.ErrorCodeGeneric},
+		{"host not allowed", fmt.Errorf("%w: 10.0.0.1", mongodb.ErrHostNotAllowed), http.StatusForbidden, models.ErrorCodeHostNotAllowed},
+		{"invalid database", fmt.Errorf("%w: a/b", services.ErrInvalidDatabaseName), http.StatusBadRequest, models.ErrorCodeInvalidName},
+		{"invalid collection", services.ErrInvalidCollectionName, http.StatusBadRequest, models.ErrorCodeInvalidName},
+		{"missing document", services.ErrDocumentNotFound, http.StatusNotFound, models.ErrorCodeDocumentNotFound},
+		{"not supported", services.ErrNotSupported, http.StatusNotImplemented, models.ErrorCodeNotSupported},
+		{"client cap", mongodb.ErrTooManyClients, http.StatusServiceUnavailable, models.ErrorCodeGeneric},
+		{"deadline", fmt.Errorf("find: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, models.ErrorCodeTimeout},
+		{"anything else", errors.New("boom"), http.StatusInternalServerError, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext("/", nil)
+			sendServiceError(c, tt.err)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			var body models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("response is not an error response: %v", err)
+			}
+			if body.Code != tt.code || body.Error != tt.err.Error() {
+				t.Errorf("body = %+v, want code %d and message %q", body, tt.code, tt.err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/services"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type QueryController struct {
+	queryService *services.QueryService
+}
+
+func NewQueryController() *QueryController {
+	return &QueryController{
+		queryService: services.NewQueryService(),
+	}
+}
+
+// Search handles free-text search within a collection
+func (ctrl *QueryController) Search(c *gin.Context) {
+	dbName := c.Param("db")
+	collectionName := c.Param("collection")
+
+	if dbName == "" {
+		utils.SendBadRequest(c, "Database name is required")
+		return
+	}
+
+	if collectionName == "" {
+		utils.SendBadRequest(c, "Collection name is required")
+		return
+	}
+
+	if c.Query("q") == "" {
+		utils.SendBadRequest(c, "Search query (q) is required")
+		return
+	}
+
+	mode, err := extJSONModeFromRequest(c)
+	if err != nil {
+		utils.SendBadRequest(c, err.Error())
+		return
+	}
+
+	// Call service layer
+	response, err := ctrl.queryService.Search(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, c.Query("q"), listQueryFromRequest(c))
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := mode.EncodeDocuments(response.Data); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Aggregate runs a validated aggregation pipeline against a collection
+func (ctrl *QueryController) Aggregate(c *gin.Context) {
+	dbName := c.Param("db")
+	collectionName := c.Param("collection")
+
+	if dbName == "" {
+		utils.SendBadRequest(c, "Database name is required")
+		return
+	}
+
+	if collectionName == "" {
+		utils.SendBadRequest(c, "Collection name is required")
+		return
+	}
+
+	var req models.AggregateRequest
+	mode, ok := bindDocumentRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	if req.ConnectionRef == (models.ConnectionRef{}) {
+		req.ConnectionRef = connectionRefFromRequest(c)
+	}
+	if req.ConsistencyOptions == (models.ConsistencyOptions{}) {
+		req.ConsistencyOptions = consistencyFromQuery(c)
+	}
+
+	// Call service layer
+	response, err := ctrl.queryService.Aggregate(c.Request.Context(), dbName, collectionName, req)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	if err := mode.EncodeDocuments(response.Data); err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// DistinctValues returns the distinct values and counts of one or more fields
+func (ctrl *QueryController) DistinctValues(c *gin.Context) {
+	dbName := c.Param("db")
+	collectionName := c.Param("collection")
+
+	if dbName == "" {
+		utils.SendBadRequest(c, "Database name is required")
+		return
+	}
+
+	if collectionName == "" {
+		utils.SendBadRequest(c, "Collection name is required")
+		return
+	}
+
+	if c.Query("fields") == "" {
+		utils.SendBadRequest(c, "At least one field (fields) is required")
+		return
+	}
+
+	limit := utils.ValidateLimit(c.DefaultQuery("limit", "20"), 20, 1000)
+
+	// Call service layer
+	response, err := ctrl.queryService.DistinctValues(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, c.Query("fields"), c.Query("filter"), limit)
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Count returns the number of documents matching an optional filter
+func (ctrl *QueryController) Count(c *gin.Context) {
+	dbName := c.Param("db")
+	collectionName := c.Param("collection")
+
+	if dbName == "" {
+		utils.SendBadRequest(c, "Database name is required")
+		return
+	}
+
+	if collectionName == "" {
+		utils.SendBadRequest(c, "Collection name is required")
+		return
+	}
+
+	// Call service layer
+	response, err := ctrl.queryService.Count(c.Request.Context(), connectionRefFromRequest(c), consistencyFromQuery(c), dbName, collectionName, c.Query("filter"), c.Query("estimated") == "true")
+	if err != nil {
+		sendServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/services"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NDJSONContentType selects streaming responses on read endpoints
+const NDJSONContentType = "application/x-ndjson"
+
+// Streamed documents are flushed to the client in groups of this size
+const streamFlushEvery = 100
+
+// wantsNDJSON reports whether the client asked for a newline-delimited stream
+func wantsNDJSON(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), NDJSONContentType)
+}
+
+// streamLimit reads the limit query parameter for streams, where a missing
+// limit means every matching document rather than the default page size
+func streamLimit(c *gin.Context) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(raw)
+	return limit, err == nil && limit >= 0
+}
+
+// ndjsonWriter writes one JSON document per line. Headers are sent with the
+// first document so errors before it can still get a normal error response.
+type ndjsonWriter struct {
+	c       *gin.Context
+	mode    utils.ExtJSONMode
+	encoder *json.Encoder
+	written int
+}
+
+func (w *ndjsonWriter) start() {
+	if w.encoder != nil {
+		return
+	}
+	header := w.c.Writer.Header()
+	header.Set("Content-Type", NDJSONContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no") // keep reverse proxies from buffering the stream
+	w.c.Status(http.StatusOK)
+	w.c.Writer.WriteHeaderNow()
+	w.encoder = json.NewEncoder(w.c.Writer)
+}
+
+// emit is the services.DocumentEmitter for the stream; a write error means
+// the client is gone and stops the cursor
+func (w *ndjsonWriter) emit(doc bson.M) error {
+	doc, err := w.mode.EncodeDocument(doc)
+	if err != nil {
+		return err
+	}
+	w.start()
+	if err := w.encoder.Encode(doc); err != nil {
+		return err
+	}
+	w.written++
+	if w.written%streamFlushEvery == 0 {
+		w.c.Writer.Flush()
+	}
+	return nil
+}
+
+// streamNDJSON runs stream and writes its documents as NDJSON. Errors after
+// the first document can no longer change the status, so they are reported
+// as a final {"error": ..., "code": ...} line.
+func streamNDJSON(c *gin.Context, mode utils.ExtJSONMode, stream func(emit services.DocumentEmitter) error) {
+	w := &ndjsonWriter{c: c, mode: mode}
+	err := stream(w.emit)
+
+	switch {
+	case err == nil:
+		w.start()
+	case w.encoder == nil:
+		sendServiceError(c, err)
+		return
+	case c.Request.Context().Err() != nil:
+		return // client disconnected, nobody to tell
+	default:
+		_ = w.encoder.Encode(models.ErrorResponse{Error: err.Error(), Code: models.ErrorCodeGeneric})
+	}
+	w.c.Writer.Flush()
+}
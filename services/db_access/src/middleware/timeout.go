@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeoutHeader lets a caller ask for a shorter deadline than the
+// configured operation timeouts. Values are Go durations ("2s", "500ms") or a
+// plain number of milliseconds.
+const RequestTimeoutHeader = "X-Request-Timeout"
+
+// RequestTimeout attaches the deadline from RequestTimeoutHeader to the request
+// context. Service timeouts still apply, so the header can only shorten a call.
+func RequestTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.GetHeader(RequestTimeoutHeader)
+		if value == "" {
+			c.Next()
+			return
+		}
+
+		timeout, err := parseRequestTimeout(value)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error(), models.ErrorCodeInvalidOption)
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// parseRequestTimeout accepts a positive duration or millisecond count
+func parseRequestTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		ms, convErr := strconv.ParseInt(value, 10, 64)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid %s header %q: expected a duration such as \"2s\" or milliseconds", RequestTimeoutHeader, value)
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s header %q: must be positive", RequestTimeoutHeader, value)
+	}
+	return timeout, nil
+}
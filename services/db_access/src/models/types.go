@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -8,10 +9,11 @@ import (
 
 // Database allocation request
 type DatabaseAllocationRequest struct {
-	DBName   string `json:"db_name" binding:"required"`
-	UserName string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	MongoURI string `json:"mongo_uri,omitempty"` // Optional for Method 3
+	DBName      string `json:"db_name" binding:"required"`
+	UserName    string `json:"username" binding:"required"`
+	Password    string `json:"password" binding:"required"`
+	MongoURI    string `json:"mongo_uri,omitempty"`     // Optional for Method 3
+	MongoURIEnc string `json:"mongo_uri_enc,omitempty"` // AES encrypted alternative to mongo_uri
 }
 
 // Database allocation response
@@ -37,20 +39,91 @@ type CreateDocumentResponse struct {
 	Code       int         `json:"code"`
 }
 
+// Bulk insert request for path-based routes
+type BulkInsertRequest struct {
+	Documents []map[string]interface{} `json:"documents" binding:"required"`
+	Ordered   *bool                    `json:"ordered,omitempty"`    // default true: stop at the first failure
+	BatchSize int                      `json:"batch_size,omitempty"` // documents per InsertMany, default 500, at most 1000
+}
+
+// Outcome of one input document of a bulk insert
+type BulkInsertResult struct {
+	Index      int         `json:"index"`
+	Status     string      `json:"status"` // inserted, failed or skipped
+	InsertedID interface{} `json:"inserted_id,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Bulk insert response with one result per input document
+type BulkInsertResponse struct {
+	Message           string             `json:"message"`
+	Database          string             `json:"database"`
+	Collection        string             `json:"collection"`
+	Ordered           bool               `json:"ordered"`
+	InsertedCount     int                `json:"inserted_count"`
+	FailedCount       int                `json:"failed_count"`
+	SkippedCount      int                `json:"skipped_count"`
+	Results           []BulkInsertResult `json:"results"`
+	WriteConcernError string             `json:"write_concern_error,omitempty"`
+	Code              int                `json:"code"`
+}
+
+// Update-many or delete-many by filter. Without confirm_token the call is a
+// dry run that returns the token to echo back.
+type BulkWriteRequest struct {
+	Filter       json.RawMessage `json:"filter" binding:"required"` // JSON object or compact "field:op:value" string
+	Update       json.RawMessage `json:"update,omitempty"`          // update-many only: $set, $unset and $inc
+	ConfirmToken string          `json:"confirm_token,omitempty"`
+}
+
+// Bulk write response for both the dry run and the confirmed call
+type BulkWriteResponse struct {
+	Message       string        `json:"message"`
+	Database      string        `json:"database"`
+	Collection    string        `json:"collection"`
+	Operation     string        `json:"operation"` // update or delete
+	DryRun        bool          `json:"dry_run"`
+	MatchedCount  int64         `json:"matched_count"`
+	SampleIDs     []interface{} `json:"sample_ids,omitempty"` // dry run only
+	Limit         int           `json:"limit"`                // most documents one call may touch
+	ConfirmToken  string        `json:"confirm_token,omitempty"`
+	ExpiresAt     *time.Time    `json:"expires_at,omitempty"`
+	ModifiedCount int64         `json:"modified_count"`
+	DeletedCount  int64         `json:"deleted_count"`
+	Code          int           `json:"code"`
+}
+
 // Document update request
 type UpdateDocumentRequest struct {
 	Data map[string]interface{} `json:"data" binding:"required"`
 }
 
+// Document patch request: an update document using $set, $unset, $inc,
+// $push, $pull, $addToSet or $currentDate
+type PatchDocumentRequest struct {
+	Update json.RawMessage `json:"update" binding:"required"`
+}
+
+// Write options for single document updates, read from query parameters
+type UpdateOptions struct {
+	Upsert         bool   // create the document when no document has the ID
+	Replace        bool   // PUT only: replace the whole document instead of $set
+	ReturnDocument bool   // include the document as it is after the update
+	IfMatch        string // If-Match header: only update this version of the document
+}
+
 // Document update response
 type UpdateDocumentResponse struct {
-	Message       string `json:"message"`
-	Database      string `json:"database"`
-	Collection    string `json:"collection"`
-	DocumentID    string `json:"document_id"`
-	MatchedCount  int64  `json:"matched_count"`
-	ModifiedCount int64  `json:"modified_count"`
-	Code          int    `json:"code"`
+	Message       string      `json:"message"`
+	Database      string      `json:"database"`
+	Collection    string      `json:"collection"`
+	DocumentID    string      `json:"document_id"`
+	MatchedCount  int64       `json:"matched_count"`
+	ModifiedCount int64       `json:"modified_count"` // assumed 1 when the returned document was matched
+	UpsertedID    interface{} `json:"upserted_id,omitempty"`
+	Document      bson.M      `json:"document,omitempty"` // with return_document=true
+	ETag          string      `json:"etag,omitempty"`     // version after the update
+	Code          int         `json:"code"`
 }
 
 // Document deletion response
@@ -65,16 +138,35 @@ type DeleteDocumentResponse struct {
 
 // Collection entries response
 type CollectionEntriesResponse struct {
-	Message    string   `json:"message"`
-	Database   string   `json:"database"`
-	Collection string   `json:"collection"`
-	Data       []bson.M `json:"data"`
-	Count      int      `json:"count"`
-	TotalCount int64    `json:"total_count"`
-	Limit      int      `json:"limit"`
-	Skip       int      `json:"skip"`
-	HasMore    bool     `json:"has_more"`
-	Code       int      `json:"code"`
+	Message    string        `json:"message"`
+	Database   string        `json:"database"`
+	Collection string        `json:"collection"`
+	Data       []bson.M      `json:"data"`
+	Count      int           `json:"count"`
+	TotalCount int64         `json:"total_count"` // -1 when not counted (cursor pages)
+	Limit      int           `json:"limit"`
+	Skip       int           `json:"skip"`
+	HasMore    bool          `json:"has_more"`
+	NextCursor string        `json:"next_cursor,omitempty"` // pass back as cursor for the next page
+	Estimated  bool          `json:"total_estimated,omitempty"`
+	SearchMode string        `json:"search_mode,omitempty"` // "text" or "regex" for search results
+	Explain    *QueryExplain `json:"explain,omitempty"`     // set instead of data when explain was requested
+	Code       int           `json:"code"`
+}
+
+// Simplified query plan returned by explain mode
+type QueryExplain struct {
+	Operation          string   `json:"operation"`    // find or aggregate
+	WinningPlan        string   `json:"winning_plan"` // stages from root to leaf, e.g. "LIMIT > FETCH > IXSCAN"
+	IndexesUsed        []string `json:"indexes_used"`
+	CollectionScan     bool     `json:"collection_scan"`
+	PipelineStages     []string `json:"pipeline_stages,omitempty"` // aggregation stages run after the query
+	DocsExamined       int64    `json:"docs_examined"`
+	KeysExamined       int64    `json:"keys_examined"`
+	DocsReturned       int64    `json:"docs_returned"`
+	ExecutionTimeMS    int64    `json:"execution_time_ms"`
+	EstimatedDocuments int64    `json:"estimated_documents"` // collection size from metadata
+	Warnings           []string `json:"warnings"`
 }
 
 // Enhanced field statistics for form generation
@@ -118,45 +210,166 @@ type SchemaDetectionResponse struct {
 // Collection information
 type CollectionInfo struct {
 	Name          string `json:"name"`
-	DocumentCount int64  `json:"document_count"`
+	DocumentCount int64  `json:"document_count"`  // -1 when counting failed
+	Error         string `json:"error,omitempty"` // why counting failed
 }
 
 // Collections list response
 type CollectionsListResponse struct {
-	Message     string           `json:"message"`
-	Database    string           `json:"database"`
-	Collections []CollectionInfo `json:"collections"`
-	Total       int              `json:"total"`
-	Code        int              `json:"code"`
+	Message         string           `json:"message"`
+	Database        string           `json:"database"`
+	Collections     []CollectionInfo `json:"collections"`
+	Total           int              `json:"total"`
+	CountsEstimated bool             `json:"counts_estimated,omitempty"`
+	Code            int              `json:"code"`
+}
+
+// Document count response
+type CountResponse struct {
+	Message    string `json:"message"`
+	Database   string `json:"database"`
+	Collection string `json:"collection"`
+	Count      int64  `json:"count"`
+	Estimated  bool   `json:"estimated"`
+	Code       int    `json:"code"`
 }
 
 // Document analysis request
 type DocumentAnalysisRequest struct {
+	ConnectionRef
 	DBName      string   `json:"db_name" binding:"required"`
 	Collections []string `json:"collections" binding:"required"`
 }
 
+// Reference to an external MongoDB connection: a registered connection handle,
+// an AES encrypted URI, or a raw URI (kept for backward compatibility)
+type ConnectionRef struct {
+	ConnectionID string `json:"connection_id,omitempty"`
+	MongoURIEnc  string `json:"mongo_uri_enc,omitempty"`
+	MongoURI     string `json:"mongo_uri,omitempty"`
+}
+
+// Optional per-request consistency settings. Empty values fall back to the
+// configured defaults.
+type ConsistencyOptions struct {
+	ReadPreference string `json:"read_preference,omitempty" form:"read_preference"`
+	ReadConcern    string `json:"read_concern,omitempty" form:"read_concern"`
+	WriteConcern   string `json:"write_concern,omitempty" form:"write_concern"`
+}
+
+// PEM encoded TLS material for self-hosted MongoDB deployments
+type TLSOptions struct {
+	CAPEM   string `json:"ca_pem,omitempty"`   // CA bundle replacing the system roots
+	CertPEM string `json:"cert_pem,omitempty"` // Client certificate for X.509 auth
+	KeyPEM  string `json:"key_pem,omitempty"`  // Private key for cert_pem
+}
+
+// Connection registration request (one of mongo_uri or mongo_uri_enc is required)
+type CreateConnectionRequest struct {
+	MongoURI     string      `json:"mongo_uri,omitempty"`
+	MongoURIEnc  string      `json:"mongo_uri_enc,omitempty"`
+	DatabaseName string      `json:"database_name" binding:"required"`
+	TTLSeconds   int         `json:"ttl_seconds,omitempty"`
+	TLS          *TLSOptions `json:"tls,omitempty"`
+}
+
+// Connection registration response
+type ConnectionResponse struct {
+	Message      string    `json:"message"`
+	ConnectionID string    `json:"connection_id"`
+	Database     string    `json:"database"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	TTLSeconds   int       `json:"ttl_seconds"`
+	Code         int       `json:"code"`
+}
+
 // Method 3 schema analysis request (using external MongoDB URI)
 type Method3SchemaRequest struct {
-	MongoURI       string `json:"mongo_uri" binding:"required"`
+	ConnectionRef
+	ConsistencyOptions
 	DatabaseName   string `json:"database_name" binding:"required"`
 	CollectionName string `json:"collection_name" binding:"required"`
 }
 
 // Method 3 data insertion request (using external MongoDB URI)
 type Method3DataInsertRequest struct {
-	MongoURI       string                 `json:"mongo_uri" binding:"required"`
+	ConnectionRef
+	ConsistencyOptions
 	DatabaseName   string                 `json:"database_name" binding:"required"`
 	CollectionName string                 `json:"collection_name" binding:"required"`
 	Data           map[string]interface{} `json:"data" binding:"required"`
 }
 
-// Method 3 data operations request (using external MongoDB URI)
-type Method3DataRequest struct {
-	MongoURI       string `json:"mongo_uri" binding:"required"`
+// Method 3 bulk insert request (using external MongoDB URI)
+type Method3BulkInsertRequest struct {
+	ConnectionRef
+	ConsistencyOptions
+	BulkInsertRequest
 	DatabaseName   string `json:"database_name" binding:"required"`
 	CollectionName string `json:"collection_name" binding:"required"`
-	DocumentID     string `json:"document_id,omitempty"` // For delete operations
+}
+
+// Method 3 data operations request (using external MongoDB URI)
+type Method3DataRequest struct {
+	ConnectionRef
+	ConsistencyOptions
+	DatabaseName   string          `json:"database_name" binding:"required"`
+	CollectionName string          `json:"collection_name" binding:"required"`
+	DocumentID     string          `json:"document_id,omitempty"` // For delete operations
+	Filter         json.RawMessage `json:"filter,omitempty"`      // JSON object or compact "field:op:value" string
+	Sort           string          `json:"sort,omitempty"`        // e.g. "-total,name"
+	Fields         string          `json:"fields,omitempty"`      // e.g. "name,total" or "-payload"
+	Limit          int             `json:"limit,omitempty"`       // default 50, capped at 1000; NDJSON streams default to all
+	Skip           int             `json:"skip,omitempty"`
+	Cursor         string          `json:"cursor,omitempty"`  // next_cursor from the previous page
+	Count          string          `json:"count,omitempty"`   // exact (default), estimated or none
+	Explain        bool            `json:"explain,omitempty"` // return the query plan instead of documents
+}
+
+// Aggregation request for path-based routes. The connection may also be given
+// in headers.
+type AggregateRequest struct {
+	ConnectionRef
+	ConsistencyOptions
+	Pipeline  json.RawMessage `json:"pipeline" binding:"required"` // array of stages, Extended JSON allowed
+	MaxTimeMS int             `json:"max_time_ms,omitempty"`       // capped at MEDIUM_TIMEOUT
+	Limit     int             `json:"limit,omitempty"`             // output document cap, at most 1000
+	Explain   bool            `json:"explain,omitempty"`           // return the query plan instead of documents
+}
+
+// Aggregation response
+type AggregateResponse struct {
+	Message    string        `json:"message"`
+	Database   string        `json:"database"`
+	Collection string        `json:"collection"`
+	Data       []bson.M      `json:"data"`
+	Count      int           `json:"count"`
+	Truncated  bool          `json:"truncated"` // more results existed beyond the output cap
+	Explain    *QueryExplain `json:"explain,omitempty"`
+	Code       int           `json:"code"`
+}
+
+// One distinct value of a field and how many documents hold it
+type ValueCount struct {
+	Value interface{} `json:"value"` // null covers documents where the field is missing
+	Count int64       `json:"count"`
+}
+
+// Distinct values of a single field, most frequent first
+type FieldValueCounts struct {
+	Values        []ValueCount `json:"values"`
+	DistinctCount int64        `json:"distinct_count"`
+	Truncated     bool         `json:"truncated"` // more distinct values exist than limit
+}
+
+// Distinct values response, keyed by field path
+type DistinctValuesResponse struct {
+	Message    string                      `json:"message"`
+	Database   string                      `json:"database"`
+	Collection string                      `json:"collection"`
+	Fields     map[string]FieldValueCounts `json:"fields"`
+	Limit      int                         `json:"limit"`
+	Code       int                         `json:"code"`
 }
 
 // Document analysis result
@@ -186,12 +399,39 @@ type SampleDataResponse struct {
 	Code       int      `json:"code"`
 }
 
-// Query parameters for pagination
+// Query parameters for document listing
 type QueryParams struct {
-	Limit int `form:"limit"`
-	Skip  int `form:"skip"`
+	Limit   int    `form:"limit"`
+	Skip    int    `form:"skip"`
+	Filter  string `form:"filter"`  // JSON object or compact "field:op:value" list
+	Sort    string `form:"sort"`    // e.g. "-total,name"
+	Fields  string `form:"fields"`  // e.g. "name,total" or "-payload"
+	Cursor  string `form:"cursor"`  // next_cursor from the previous page, replaces skip
+	Count   string `form:"count"`   // exact, estimated or none
+	Explain bool   `form:"explain"` // return the query plan instead of documents
 }
 
+// Error codes returned in ErrorResponse.Code
+const (
+	ErrorCodeGeneric            = 1
+	ErrorCodeConnectionRequired = 1001
+	ErrorCodeConnectionNotFound = 1002
+	ErrorCodeHostNotAllowed     = 1003
+	ErrorCodeInvalidTLSOptions  = 1004
+	ErrorCodeTLSHandshake       = 1005
+	ErrorCodeInvalidOption      = 1006
+	ErrorCodeTimeout            = 1007
+	ErrorCodeInvalidName        = 1008
+	ErrorCodeDocumentNotFound   = 1009
+	ErrorCodeNotSupported       = 1010
+	ErrorCodeInvalidFilter      = 1011
+	ErrorCodeInvalidPipeline    = 1012
+	ErrorCodeInvalidUpdate      = 1013
+	ErrorCodeConfirmation       = 1014
+	ErrorCodeWriteLimit         = 1015
+	ErrorCodePreconditionFailed = 1016
+)
+
 // Common error response
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -226,6 +466,49 @@ type DocumentFilter struct {
 	ID     string
 }
 
+// Liveness response
+type LivenessResponse struct {
+	Status        string `json:"status"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+}
+
+// Readiness response with dependency status
+type ReadinessResponse struct {
+	Status        string              `json:"status"`
+	Config        ConfigStatus        `json:"config"`
+	Build         BuildInfo           `json:"build"`
+	StartedAt     time.Time           `json:"started_at"`
+	UptimeSeconds int64               `json:"uptime_seconds"`
+	Goroutines    int                 `json:"goroutines"`
+	MongoClients  []MongoClientStatus `json:"mongo_clients"`
+}
+
+// Configuration validity
+type ConfigStatus struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// Build metadata
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Cached external MongoDB client status, identified only by an opaque ID
+type MongoClientStatus struct {
+	ID                string     `json:"id"`
+	State             string     `json:"state"`
+	ActiveUsers       int        `json:"active_users"`
+	PoolInUse         int64      `json:"pool_in_use"`
+	PoolIdle          int64      `json:"pool_idle"`
+	LastUsed          time.Time  `json:"last_used"`
+	LastPingAt        *time.Time `json:"last_ping_at,omitempty"`
+	LastPingLatencyMS float64    `json:"last_ping_latency_ms"`
+}
+
 // Context timeout configuration
 type ContextConfig struct {
 	ShortTimeout  time.Duration
@@ -242,7 +525,8 @@ var DefaultContextConfig = ContextConfig{
 
 // Schema modification request for adding fields
 type Method3SchemaModificationRequest struct {
-	MongoURI       string                 `json:"mongo_uri" binding:"required"`
+	ConnectionRef
+	ConsistencyOptions
 	DatabaseName   string                 `json:"database_name" binding:"required"`
 	CollectionName string                 `json:"collection_name" binding:"required"`
 	NewFields      map[string]interface{} `json:"new_fields" binding:"required"`
@@ -250,7 +534,8 @@ type Method3SchemaModificationRequest struct {
 
 // Schema field removal request
 type Method3SchemaFieldRemovalRequest struct {
-	MongoURI       string `json:"mongo_uri" binding:"required"`
+	ConnectionRef
+	ConsistencyOptions
 	DatabaseName   string `json:"database_name" binding:"required"`
 	CollectionName string `json:"collection_name" binding:"required"`
 	FieldName      string `json:"field_name" binding:"required"`
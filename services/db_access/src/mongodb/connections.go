@@ -0,0 +1,123 @@
+package mongodb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrConnectionNotFound is returned for unknown or expired connection handles
+var ErrConnectionNotFound = errors.New("connection not found or expired")
+
+// Connection is a registered external MongoDB connection. The URI never leaves
+// the service once registered; callers refer to it by ID.
+type Connection struct {
+	ID        string
+	URI       string
+	Database  string
+	TLS       *TLSOptions
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// ConnectionStore keeps registered connection handles in memory
+type ConnectionStore struct {
+	mu          sync.Mutex
+	connections map[string]*Connection
+}
+
+var connectionStore = NewConnectionStore()
+
+// NewConnectionStore creates an empty connection store
+func NewConnectionStore() *ConnectionStore {
+	return &ConnectionStore{connections: make(map[string]*Connection)}
+}
+
+// RegisterConnection stores a validated URI in the shared store
+func RegisterConnection(mongoURI, database string, tlsOpts *TLSOptions, ttl time.Duration) (*Connection, error) {
+	return connectionStore.Register(mongoURI, database, tlsOpts, ttl)
+}
+
+// ResolveConnection looks up a handle in the shared store
+func ResolveConnection(id string) (*Connection, error) {
+	return connectionStore.Resolve(id)
+}
+
+// RemoveConnection deletes a handle from the shared store
+func RemoveConnection(id string) bool {
+	return connectionStore.Remove(id)
+}
+
+// Register stores mongoURI and its TLS material under a new random ID that
+// expires after ttl
+func (s *ConnectionStore) Register(mongoURI, database string, tlsOpts *TLSOptions, ttl time.Duration) (*Connection, error) {
+	id, err := newConnectionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	conn := &Connection{
+		ID:        id,
+		URI:       mongoURI,
+		Database:  database,
+		TLS:       tlsOpts,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweepLocked(now)
+	s.connections[id] = conn
+	return conn, nil
+}
+
+// Resolve returns the connection for id if it exists and has not expired
+func (s *ConnectionStore) Resolve(id string) (*Connection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conn, ok := s.connections[id]
+	if !ok {
+		return nil, ErrConnectionNotFound
+	}
+	if time.Now().After(conn.ExpiresAt) {
+		delete(s.connections, id)
+		return nil, ErrConnectionNotFound
+	}
+
+	copied := *conn
+	return &copied, nil
+}
+
+// Remove deletes a handle and reports whether it existed
+func (s *ConnectionStore) Remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.connections[id]
+	delete(s.connections, id)
+	return ok
+}
+
+// sweepLocked drops expired handles
+func (s *ConnectionStore) sweepLocked(now time.Time) {
+	for id, conn := range s.connections {
+		if now.After(conn.ExpiresAt) {
+			delete(s.connections, id)
+		}
+	}
+}
+
+// newConnectionID generates an opaque, unguessable handle
+func newConnectionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "conn_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,161 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// ErrInvalidConsistencyOption is returned for unknown read preference, read
+// concern or write concern values
+var ErrInvalidConsistencyOption = errors.New("invalid consistency option")
+
+// maxWriteConcernW bounds numeric write concerns to a sane replica set size
+const maxWriteConcernW = 50
+
+// ConsistencyDefaults are applied when a request leaves a setting empty. An
+// empty default keeps the driver/server default.
+type ConsistencyDefaults struct {
+	ReadPreference       string
+	ReadConcern          string
+	WriteConcern         string
+	SchemaReadPreference string // used by schema detection, which is read heavy
+}
+
+var (
+	consistencyMu       sync.RWMutex
+	consistencyDefaults = ConsistencyDefaults{SchemaReadPreference: "secondaryPreferred"}
+)
+
+// ConfigureConsistencyDefaults validates and installs service-wide defaults
+func ConfigureConsistencyDefaults(defaults ConsistencyDefaults) error {
+	for _, readPref := range []string{defaults.ReadPreference, defaults.SchemaReadPreference} {
+		if _, err := parseReadPreference(readPref); err != nil {
+			return err
+		}
+	}
+	if _, err := parseReadConcern(defaults.ReadConcern); err != nil {
+		return err
+	}
+	if _, err := parseWriteConcern(defaults.WriteConcern); err != nil {
+		return err
+	}
+
+	consistencyMu.Lock()
+	defer consistencyMu.Unlock()
+	consistencyDefaults = defaults
+	return nil
+}
+
+// SchemaReadPreference returns the default read preference for schema detection
+func SchemaReadPreference() string {
+	consistencyMu.RLock()
+	defer consistencyMu.RUnlock()
+	return consistencyDefaults.SchemaReadPreference
+}
+
+// CollectionOptions maps request level read preference, read concern and
+// write concern onto driver collection options, falling back to defaults
+func CollectionOptions(readPreference, readConcern, writeConcern string) (*options.CollectionOptions, error) {
+	consistencyMu.RLock()
+	defaults := consistencyDefaults
+	consistencyMu.RUnlock()
+
+	if readPreference == "" {
+		readPreference = defaults.ReadPreference
+	}
+	if readConcern == "" {
+		readConcern = defaults.ReadConcern
+	}
+	if writeConcern == "" {
+		writeConcern = defaults.WriteConcern
+	}
+
+	collOpts := options.Collection()
+
+	readPref, err := parseReadPreference(readPreference)
+	if err != nil {
+		return nil, err
+	}
+	if readPref != nil {
+		collOpts.SetReadPreference(readPref)
+	}
+
+	rc, err := parseReadConcern(readConcern)
+	if err != nil {
+		return nil, err
+	}
+	if rc != nil {
+		collOpts.SetReadConcern(rc)
+	}
+
+	wc, err := parseWriteConcern(writeConcern)
+	if err != nil {
+		return nil, err
+	}
+	if wc != nil {
+		collOpts.SetWriteConcern(wc)
+	}
+
+	return collOpts, nil
+}
+
+// parseReadPreference accepts the five standard read preference modes
+func parseReadPreference(value string) (*readpref.ReadPref, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	mode, err := readpref.ModeFromString(value)
+	if err != nil || mode == readpref.Mode(0) {
+		return nil, fmt.Errorf("%w: read_preference must be one of primary, primaryPreferred, secondary, secondaryPreferred, nearest (got %q)", ErrInvalidConsistencyOption, value)
+	}
+
+	readPref, err := readpref.New(mode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConsistencyOption, err)
+	}
+	return readPref, nil
+}
+
+// parseReadConcern accepts the read concern levels usable outside transactions
+func parseReadConcern(value string) (*readconcern.ReadConcern, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return nil, nil
+	case "local":
+		return readconcern.Local(), nil
+	case "available":
+		return readconcern.Available(), nil
+	case "majority":
+		return readconcern.Majority(), nil
+	case "linearizable":
+		return readconcern.Linearizable(), nil
+	default:
+		return nil, fmt.Errorf("%w: read_concern must be one of local, available, majority, linearizable (got %q)", ErrInvalidConsistencyOption, value)
+	}
+}
+
+// parseWriteConcern accepts "majority" or a numeric acknowledgment count.
+// w=0 is refused: unacknowledged writes report no result or error.
+func parseWriteConcern(value string) (*writeconcern.WriteConcern, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if strings.ToLower(value) == "majority" {
+		return writeconcern.New(writeconcern.WMajority()), nil
+	}
+
+	w, err := strconv.Atoi(value)
+	if err != nil || w < 1 || w > maxWriteConcernW {
+		return nil, fmt.Errorf("%w: write_concern must be \"majority\" or a number between 1 and %d (got %q)", ErrInvalidConsistencyOption, maxWriteConcernW, value)
+	}
+	return writeconcern.New(writeconcern.W(w)), nil
+}
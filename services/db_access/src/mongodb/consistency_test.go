@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestParseWriteConcern(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantW   interface{}
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"majority", "majority", false},
+		{"MAJORITY", "majority", false},
+		{"1", 1, false},
+		{"50", 50, false},
+		{"0", nil, true},
+		{"-1", nil, true},
+		{"51", nil, true},
+		{"all", nil, true},
+	}
+
+	for _, tt := range tests {
+		wc, err := parseWriteConcern(tt.value)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidConsistencyOption) {
+				t.Errorf("parseWriteConcern(%q) error = %v, want ErrInvalidConsistencyOption", tt.value, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseWriteConcern(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if tt.wantW == nil {
+			if wc != nil {
+				t.Errorf("parseWriteConcern(%q) = %v, want nil", tt.value, wc)
+			}
+			continue
+		}
+		if wc.GetW() != tt.wantW || !wc.Acknowledged() {
+			t.Errorf("parseWriteConcern(%q) w = %v, want acknowledged %v", tt.value, wc.GetW(), tt.wantW)
+		}
+	}
+}
+
+func TestParseReadPreference(t *testing.T) {
+	for _, value := range []string{"primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest"} {
+		readPref, err := parseReadPreference(value)
+		if err != nil || readPref == nil {
+			t.Errorf("parseReadPreference(%q) = %v, %v", value, readPref, err)
+		}
+	}
+	if readPref, err := parseReadPreference(""); readPref != nil || err != nil {
+		t.Errorf("parseReadPreference(\"\") = %v, %v; want nil, nil", readPref, err)
+	}
+	if _, err := parseReadPreference("fastest"); !errors.Is(err, ErrInvalidConsistencyOption) {
+		t.Errorf("parseReadPreference(\"fastest\") error = %v, want ErrInvalidConsistencyOption", err)
+	}
+}
+
+func TestParseReadConcern(t *testing.T) {
+	for _, value := range []string{"", "local", "available", "Majority", "linearizable"} {
+		if _, err := parseReadConcern(value); err != nil {
+			t.Errorf("parseReadConcern(%q) returned error: %v", value, err)
+		}
+	}
+	if _, err := parseReadConcern("snapshot"); !errors.Is(err, ErrInvalidConsistencyOption) {
+		t.Errorf("parseReadConcern(\"snapshot\") error = %v, want ErrInvalidConsistencyOption", err)
+	}
+}
+
+func TestConfigureConsistencyDefaultsRejectsUnacknowledged(t *testing.T) {
+	if err := ConfigureConsistencyDefaults(ConsistencyDefaults{WriteConcern: "0"}); !errors.Is(err, ErrInvalidConsistencyOption) {
+		t.Errorf("ConfigureConsistencyDefaults error = %v, want ErrInvalidConsistencyOption", err)
+	}
+	if got := SchemaReadPreference(); got != "secondaryPreferred" {
+		t.Errorf("rejected defaults were installed: SchemaReadPreference = %q", got)
+	}
+}
+
+func TestCollectionOptions(t *testing.T) {
+	collOpts, err := CollectionOptions("secondary", "majority", "2")
+	if err != nil {
+		t.Fatalf("CollectionOptions returned error: %v", err)
+	}
+	if collOpts.ReadPreference.Mode() != readpref.SecondaryMode {
+		t.Errorf("read preference = %v, want secondary", collOpts.ReadPreference.Mode())
+	}
+	if collOpts.ReadConcern.GetLevel() != "majority" {
+		t.Errorf("read concern = %q, want majority", collOpts.ReadConcern.GetLevel())
+	}
+	if collOpts.WriteConcern.GetW() != 2 {
+		t.Errorf("write concern w = %v, want 2", collOpts.WriteConcern.GetW())
+	}
+
+	if _, err := CollectionOptions("", "", "0"); !errors.Is(err, ErrInvalidConsistencyOption) {
+		t.Errorf("CollectionOptions with w=0 error = %v, want ErrInvalidConsistencyOption", err)
+	}
+}
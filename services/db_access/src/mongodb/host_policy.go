@@ -0,0 +1,276 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrHostNotAllowed is returned when a URI points at a host the outbound
+// policy does not permit
+var ErrHostNotAllowed = errors.New("MongoDB host not allowed")
+
+// reservedNetworks are special-purpose ranges not covered by the net.IP helpers
+var reservedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"64:ff9b::/96",  // NAT64, can embed internal IPv4 addresses
+)
+
+// HostPolicy decides which hosts user-supplied URIs may dial. Deny rules always
+// win; when any allow rule is configured a host must match one of them.
+type HostPolicy struct {
+	AllowedHosts        []string // exact names or "*.example.com" suffix patterns
+	DeniedHosts         []string
+	AllowedCIDRs        []*net.IPNet
+	DeniedCIDRs         []*net.IPNet
+	BlockPrivateNetwork bool // loopback, RFC 1918, link-local (metadata) and similar ranges
+}
+
+var (
+	hostPolicyMu sync.RWMutex
+	hostPolicy   = &HostPolicy{BlockPrivateNetwork: true}
+)
+
+// NewHostPolicy builds a policy from configuration values
+func NewHostPolicy(allowedHosts, deniedHosts, allowedCIDRs, deniedCIDRs []string, blockPrivate bool) (*HostPolicy, error) {
+	allowedNets, err := parseCIDRs(allowedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed CIDR: %v", err)
+	}
+	deniedNets, err := parseCIDRs(deniedCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denied CIDR: %v", err)
+	}
+
+	return &HostPolicy{
+		AllowedHosts:        normalizeHostPatterns(allowedHosts),
+		DeniedHosts:         normalizeHostPatterns(deniedHosts),
+		AllowedCIDRs:        allowedNets,
+		DeniedCIDRs:         deniedNets,
+		BlockPrivateNetwork: blockPrivate,
+	}, nil
+}
+
+// ConfigureHostPolicy replaces the policy applied to every new connection
+func ConfigureHostPolicy(policy *HostPolicy) {
+	hostPolicyMu.Lock()
+	defer hostPolicyMu.Unlock()
+	hostPolicy = policy
+}
+
+// currentHostPolicy returns the active policy
+func currentHostPolicy() *HostPolicy {
+	hostPolicyMu.RLock()
+	defer hostPolicyMu.RUnlock()
+	return hostPolicy
+}
+
+// CheckURI validates every host a URI would dial, including the targets of a
+// mongodb+srv lookup, before any connection is attempted
+func (p *HostPolicy) CheckURI(ctx context.Context, mongoURI string) error {
+	parts, err := parseURIParts(mongoURI)
+	if err != nil {
+		return err
+	}
+
+	resolver := net.DefaultResolver
+	hostnames := parts.Hostnames()
+
+	if parts.Scheme == "mongodb+srv" {
+		if len(hostnames) != 1 {
+			return fmt.Errorf("invalid MongoDB URI: mongodb+srv requires exactly one host")
+		}
+		srvName := hostnames[0]
+		if err := p.checkHostname(srvName); err != nil {
+			return err
+		}
+
+		_, records, err := resolver.LookupSRV(ctx, "mongodb", "tcp", srvName)
+		if err != nil {
+			return fmt.Errorf("failed to resolve SRV record for %s: %v", srvName, err)
+		}
+
+		hostnames = hostnames[:0]
+		for _, record := range records {
+			hostnames = append(hostnames, strings.ToLower(strings.TrimSuffix(record.Target, ".")))
+		}
+	}
+
+	for _, host := range hostnames {
+		if err := p.checkHost(ctx, resolver, host); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkHost applies hostname rules, resolves the host and applies IP rules
+func (p *HostPolicy) checkHost(ctx context.Context, resolver *net.Resolver, host string) error {
+	if err := p.checkHostname(host); err != nil {
+		return err
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("failed to resolve MongoDB host %s: %v", host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	allIPsAllowed := len(ips) > 0
+	for _, ip := range ips {
+		if err := p.checkIP(ip); err != nil {
+			return fmt.Errorf("%w: %s resolves to %v", err, host, ip)
+		}
+		if !containsIP(p.AllowedCIDRs, ip) {
+			allIPsAllowed = false
+		}
+	}
+
+	if !p.hasAllowRules() || matchesHostPattern(p.AllowedHosts, host) || (len(p.AllowedCIDRs) > 0 && allIPsAllowed) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not in the allowlist", ErrHostNotAllowed, host)
+}
+
+// checkHostname applies the hostname deny list
+func (p *HostPolicy) checkHostname(host string) error {
+	if matchesHostPattern(p.DeniedHosts, host) {
+		return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, host)
+	}
+	return nil
+}
+
+// checkIP applies CIDR deny rules and the private network block. An explicit
+// allowed CIDR overrides the private network block, not the deny list.
+func (p *HostPolicy) checkIP(ip net.IP) error {
+	if containsIP(p.DeniedCIDRs, ip) {
+		return fmt.Errorf("%w: address is in a denied range", ErrHostNotAllowed)
+	}
+	if p.BlockPrivateNetwork && isInternalIP(ip) && !containsIP(p.AllowedCIDRs, ip) {
+		return fmt.Errorf("%w: address is on a private or internal network", ErrHostNotAllowed)
+	}
+	return nil
+}
+
+// hasAllowRules reports whether the policy is an allowlist
+func (p *HostPolicy) hasAllowRules() bool {
+	return len(p.AllowedHosts) > 0 || len(p.AllowedCIDRs) > 0
+}
+
+// Dialer returns a dialer that re-checks the resolved address at connect time,
+// which closes the DNS rebinding gap between CheckURI and the actual dial
+func (p *HostPolicy) Dialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("%w: cannot parse dial address %s", ErrHostNotAllowed, address)
+			}
+			return p.checkIP(ip)
+		},
+	}
+}
+
+// isInternalIP reports whether ip belongs to a non-public range
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		containsIP(reservedNetworks, ip)
+}
+
+// matchesHostPattern matches host against exact names and "*.suffix" patterns
+func matchesHostPattern(patterns []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "*.") {
+			if strings.HasSuffix(host, pattern[1:]) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// containsIP reports whether any network contains ip
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHostPatterns lower-cases and trims configured host patterns
+func normalizeHostPatterns(patterns []string) []string {
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(pattern), "."))
+		if pattern != "" {
+			normalized = append(normalized, pattern)
+		}
+	}
+	return normalized
+}
+
+// parseCIDRs parses CIDR strings, accepting bare IPs as single-host ranges
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// mustParseCIDRs parses built-in CIDR constants
+func mustParseCIDRs(values ...string) []*net.IPNet {
+	networks, err := parseCIDRs(values)
+	if err != nil {
+		panic(err)
+	}
+	return networks
+}
@@ -2,9 +2,8 @@ package mongodb
 
 import (
 	"context"
-	"crypto/tls"
-	"log"
 	"reflect"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,46 +12,73 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// ⚠️ SECURITY: Global MongoDB client removed for security reasons
-// All connections now managed by main server
+// Driver settings applied to every client, see ConfigureConnectionSettings
+var (
+	connectionSettingsMu sync.RWMutex
+	maxPoolSize          uint64        = 100
+	connectTimeout       time.Duration = 30 * time.Second
+)
 
-func GetClient() *mongo.Client {
-	// ⚠️ SECURITY NOTICE: This function is deprecated for security reasons
-	// MongoDB credentials should not be stored in microservices environment
-	// Use ConnectWithURI() with URIs provided by main server instead
-	log.Fatal("GetClient() deprecated: MongoDB URIs should be provided by main server, not stored in microservice environment")
-	return nil
+// ConfigureConnectionSettings sets the pool size and the connect/server
+// selection timeout used for new clients
+func ConfigureConnectionSettings(poolSize uint64, timeout time.Duration) {
+	connectionSettingsMu.Lock()
+	defer connectionSettingsMu.Unlock()
+	maxPoolSize = poolSize
+	connectTimeout = timeout
 }
 
-// ConnectWithURI connects to MongoDB using a custom URI (for Method 3)
-func ConnectWithURI(mongoURI string) (*mongo.Client, error) {
+// connect dials and pings a new client; stats, when set, receives pool events
+// and the latency of the initial ping
+func connect(mongoURI string, tlsOpts *TLSOptions, stats *PoolStats) (*mongo.Client, error) {
 	tM := reflect.TypeOf(bson.M{})
 	reg := bson.NewRegistryBuilder().RegisterTypeMapEntry(bsontype.EmbeddedDocument, tM).Build()
 
-	// Railway deployment compatibility: Use more permissive TLS config
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: false,
-		MinVersion:         tls.VersionTLS12,
-		MaxVersion:         tls.VersionTLS13,
+	// Railway deployment compatibility: system roots and TLS 1.2-1.3 unless
+	// the connection supplies its own CA bundle or client certificate
+	tlsConfig, err := BuildTLSConfig(tlsOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse internal or non-allowlisted hosts before anything is dialed
+	policy := currentHostPolicy()
+	checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := policy.CheckURI(checkCtx, mongoURI); err != nil {
+		return nil, err
 	}
 
+	connectionSettingsMu.RLock()
+	poolSize, timeout := maxPoolSize, connectTimeout
+	connectionSettingsMu.RUnlock()
+
 	clientOptions := options.Client().
 		ApplyURI(mongoURI).
 		SetRegistry(reg).
-		SetConnectTimeout(30 * time.Second).
-		SetServerSelectionTimeout(30 * time.Second).
-		SetTLSConfig(tlsConfig)
+		SetMaxPoolSize(poolSize).
+		SetConnectTimeout(timeout).
+		SetServerSelectionTimeout(timeout).
+		SetTLSConfig(tlsConfig).
+		SetDialer(policy.Dialer(timeout))
+	if stats != nil {
+		clientOptions.SetPoolMonitor(stats.Monitor())
+	}
 
 	client, err := mongo.Connect(context.TODO(), clientOptions)
 	if err != nil {
-		return nil, err
+		return nil, DescribeTLSError(err)
 	}
 
 	// Test the connection
+	pingStart := time.Now()
 	err = client.Ping(context.TODO(), nil)
 	if err != nil {
 		client.Disconnect(context.TODO())
-		return nil, err
+		return nil, DescribeTLSError(err)
+	}
+	if stats != nil {
+		stats.RecordPing(time.Since(pingStart))
 	}
 
 	return client, nil
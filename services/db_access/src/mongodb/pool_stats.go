@@ -0,0 +1,65 @@
+package mongodb
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// PoolStats tracks connection pool usage and ping latency for one client
+type PoolStats struct {
+	open            atomic.Int64
+	inUse           atomic.Int64
+	lastPingLatency atomic.Int64 // nanoseconds
+	lastPingAt      atomic.Int64 // unix nanoseconds
+}
+
+// Monitor returns a driver pool monitor that feeds these stats
+func (s *PoolStats) Monitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			switch evt.Type {
+			case event.ConnectionCreated:
+				s.open.Add(1)
+			case event.ConnectionClosed:
+				s.open.Add(-1)
+			case event.GetSucceeded:
+				s.inUse.Add(1)
+			case event.ConnectionReturned:
+				s.inUse.Add(-1)
+			}
+		},
+	}
+}
+
+// RecordPing stores the latency of a successful ping
+func (s *PoolStats) RecordPing(latency time.Duration) {
+	s.lastPingLatency.Store(int64(latency))
+	s.lastPingAt.Store(time.Now().UnixNano())
+}
+
+// InUse returns the number of checked out connections
+func (s *PoolStats) InUse() int64 {
+	if n := s.inUse.Load(); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// Idle returns the number of open connections waiting in the pool
+func (s *PoolStats) Idle() int64 {
+	if n := s.open.Load() - s.inUse.Load(); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// LastPing returns the latency and time of the last successful ping
+func (s *PoolStats) LastPing() (time.Duration, time.Time) {
+	at := s.lastPingAt.Load()
+	if at == 0 {
+		return 0, time.Time{}
+	}
+	return time.Duration(s.lastPingLatency.Load()), time.Unix(0, at)
+}
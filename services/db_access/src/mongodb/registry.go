@@ -0,0 +1,359 @@
+package mongodb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Registry defaults, overridable through ConfigureClientRegistry
+const (
+	DefaultMaxClients          = 50
+	DefaultClientIdleTimeout   = 10 * time.Minute
+	DefaultHealthCheckInterval = 30 * time.Second
+)
+
+// ErrTooManyClients is returned when every cached client slot is in use
+var ErrTooManyClients = errors.New("too many open MongoDB clients, try again later")
+
+// ClientRegistry caches connected clients per normalized URI so repeated
+// Method 3 calls reuse the driver's connection pool instead of dialing again
+type ClientRegistry struct {
+	mu                  sync.Mutex
+	clients             map[string]*registryEntry
+	maxClients          int
+	idleTimeout         time.Duration
+	healthCheckInterval time.Duration
+	janitorOnce         sync.Once
+	stop                chan struct{}
+	closed              bool
+}
+
+// registryEntry tracks one cached client. All fields except ready are guarded
+// by the registry mutex.
+type registryEntry struct {
+	ready    chan struct{} // closed once the initial connect attempt finishes
+	client   *mongo.Client
+	err      error
+	refs     int
+	lastUsed time.Time
+	lastPing time.Time
+	retired  bool // removed from the map, disconnect once refs reaches zero
+	stats    *PoolStats
+}
+
+// ClientStatus describes one cached client for health reporting. It is keyed
+// by an opaque ID and never includes hosts, credentials or the URI.
+type ClientStatus struct {
+	ID              string
+	State           string // "connecting" or "connected"
+	ActiveUsers     int
+	InUse           int64
+	Idle            int64
+	LastUsed        time.Time
+	LastPingAt      time.Time
+	LastPingLatency time.Duration
+}
+
+var clientRegistry = NewClientRegistry(DefaultMaxClients, DefaultClientIdleTimeout)
+
+// NewClientRegistry creates an empty registry
+func NewClientRegistry(maxClients int, idleTimeout time.Duration) *ClientRegistry {
+	if maxClients < 1 {
+		maxClients = DefaultMaxClients
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultClientIdleTimeout
+	}
+
+	return &ClientRegistry{
+		clients:             make(map[string]*registryEntry),
+		maxClients:          maxClients,
+		idleTimeout:         idleTimeout,
+		healthCheckInterval: DefaultHealthCheckInterval,
+		stop:                make(chan struct{}),
+	}
+}
+
+// ConfigureClientRegistry updates the limits of the shared registry
+func ConfigureClientRegistry(maxClients int, idleTimeout time.Duration) {
+	clientRegistry.mu.Lock()
+	defer clientRegistry.mu.Unlock()
+
+	if maxClients > 0 {
+		clientRegistry.maxClients = maxClients
+	}
+	if idleTimeout > 0 {
+		clientRegistry.idleTimeout = idleTimeout
+	}
+}
+
+// AcquireClient returns a pooled client for the given URI and optional TLS
+// material from the shared registry. The caller must invoke release once it
+// is done with the client.
+func AcquireClient(mongoURI string, tlsOpts *TLSOptions) (*mongo.Client, func(), error) {
+	return clientRegistry.Acquire(mongoURI, tlsOpts)
+}
+
+// CloseAllClients disconnects every client held by the shared registry
+func CloseAllClients(ctx context.Context) error {
+	return clientRegistry.Close(ctx)
+}
+
+// CachedClientStatuses reports the state of every client in the shared registry
+func CachedClientStatuses() []ClientStatus {
+	return clientRegistry.Statuses()
+}
+
+// Acquire returns a connected client for mongoURI, connecting on first use and
+// reconnecting when the cached client stopped answering pings
+func (r *ClientRegistry) Acquire(mongoURI string, tlsOpts *TLSOptions) (*mongo.Client, func(), error) {
+	key, err := NormalizeURI(mongoURI)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fingerprint := tlsOpts.Fingerprint(); fingerprint != "" {
+		key += "#tls=" + fingerprint
+	}
+
+	r.janitorOnce.Do(func() { go r.janitor() })
+
+	// A stale client gets one reconnect attempt before the error is surfaced
+	for attempt := 0; attempt < 2; attempt++ {
+		entry, created, err := r.reserve(key, mongoURI)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if created {
+			client, connectErr := connect(mongoURI, tlsOpts, entry.stats)
+			r.mu.Lock()
+			entry.client, entry.err = client, connectErr
+			entry.lastPing = time.Now()
+			if connectErr != nil {
+				entry.refs--
+				r.retireLocked(key, entry)
+			}
+			r.mu.Unlock()
+			close(entry.ready)
+
+			if connectErr != nil {
+				return nil, nil, connectErr
+			}
+			return client, r.releaseFunc(entry), nil
+		}
+
+		<-entry.ready
+		r.mu.Lock()
+		if entry.err != nil {
+			entry.refs--
+			r.mu.Unlock()
+			return nil, nil, entry.err
+		}
+		needsPing := time.Since(entry.lastPing) > r.healthCheckInterval
+		r.mu.Unlock()
+
+		if !needsPing {
+			return entry.client, r.releaseFunc(entry), nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		pingStart := time.Now()
+		pingErr := entry.client.Ping(ctx, nil)
+		cancel()
+
+		r.mu.Lock()
+		if pingErr == nil {
+			entry.stats.RecordPing(time.Since(pingStart))
+			entry.lastPing = time.Now()
+			r.mu.Unlock()
+			return entry.client, r.releaseFunc(entry), nil
+		}
+
+		// Drop the broken client and connect again on the next iteration
+		log.Printf("Cached MongoDB client failed health check, reconnecting: %v", pingErr)
+		entry.refs--
+		r.retireLocked(key, entry)
+		r.mu.Unlock()
+	}
+
+	return nil, nil, errors.New("failed to reconnect to MongoDB")
+}
+
+// reserve looks up or creates the entry for key and takes a reference on it
+func (r *ClientRegistry) reserve(key, mongoURI string) (*registryEntry, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, false, errors.New("MongoDB client registry is closed")
+	}
+
+	if entry, ok := r.clients[key]; ok {
+		entry.refs++
+		entry.lastUsed = time.Now()
+		return entry, false, nil
+	}
+
+	if len(r.clients) >= r.maxClients && !r.evictIdleLocked() {
+		return nil, false, ErrTooManyClients
+	}
+
+	entry := &registryEntry{
+		ready:    make(chan struct{}),
+		refs:     1,
+		lastUsed: time.Now(),
+		stats:    &PoolStats{},
+	}
+	r.clients[key] = entry
+	return entry, true, nil
+}
+
+// Statuses returns a snapshot of the cached clients sorted by ID
+func (r *ClientRegistry) Statuses() []ClientStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]ClientStatus, 0, len(r.clients))
+	for key, entry := range r.clients {
+		sum := sha256.Sum256([]byte(key))
+		status := ClientStatus{
+			ID:          hex.EncodeToString(sum[:6]),
+			State:       "connecting",
+			ActiveUsers: entry.refs,
+			InUse:       entry.stats.InUse(),
+			Idle:        entry.stats.Idle(),
+			LastUsed:    entry.lastUsed,
+		}
+		if entry.client != nil {
+			status.State = "connected"
+		}
+		status.LastPingLatency, status.LastPingAt = entry.stats.LastPing()
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].ID < statuses[j].ID })
+	return statuses
+}
+
+// releaseFunc returns a callback that drops one reference on entry
+func (r *ClientRegistry) releaseFunc(entry *registryEntry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			entry.refs--
+			entry.lastUsed = time.Now()
+			if entry.retired && entry.refs == 0 && !r.closed {
+				go disconnectClient(entry.client)
+			}
+		})
+	}
+}
+
+// evictIdleLocked retires the least recently used client that has no active
+// users. It reports whether a slot was freed.
+func (r *ClientRegistry) evictIdleLocked() bool {
+	var oldestKey string
+	var oldest *registryEntry
+	for key, entry := range r.clients {
+		if entry.refs > 0 || entry.client == nil {
+			continue
+		}
+		if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+			oldestKey, oldest = key, entry
+		}
+	}
+
+	if oldest == nil {
+		return false
+	}
+	r.retireLocked(oldestKey, oldest)
+	return true
+}
+
+// retireLocked removes entry from the map and disconnects it once unused
+func (r *ClientRegistry) retireLocked(key string, entry *registryEntry) {
+	if r.clients[key] == entry {
+		delete(r.clients, key)
+	}
+	if entry.retired {
+		return
+	}
+	entry.retired = true
+	if entry.refs == 0 && entry.client != nil {
+		go disconnectClient(entry.client)
+	}
+}
+
+// janitor periodically disconnects clients that have been idle too long
+func (r *ClientRegistry) janitor() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			for key, entry := range r.clients {
+				if entry.refs == 0 && entry.client != nil && time.Since(entry.lastUsed) > r.idleTimeout {
+					r.retireLocked(key, entry)
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Close disconnects all cached clients and rejects further acquisitions
+func (r *ClientRegistry) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.stop)
+
+	clients := make([]*mongo.Client, 0, len(r.clients))
+	for key, entry := range r.clients {
+		delete(r.clients, key)
+		entry.retired = true
+		if entry.client != nil {
+			clients = append(clients, entry.client)
+		}
+	}
+	r.mu.Unlock()
+
+	var firstErr error
+	for _, client := range clients {
+		if err := client.Disconnect(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// disconnectClient closes a client in the background
+func disconnectClient(client *mongo.Client) {
+	if client == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := client.Disconnect(ctx); err != nil {
+		log.Printf("Failed to disconnect cached MongoDB client: %v", err)
+	}
+}
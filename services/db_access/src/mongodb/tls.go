@@ -0,0 +1,154 @@
+package mongodb
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTLSOptions is returned when supplied PEM material cannot be used
+var ErrInvalidTLSOptions = errors.New("invalid TLS options")
+
+// ErrTLSHandshake is returned when a connection fails during the TLS handshake
+var ErrTLSHandshake = errors.New("TLS handshake failed")
+
+// TLSOptions carries per-connection PEM material for self-hosted deployments
+type TLSOptions struct {
+	CAPEM   string // CA bundle used instead of the system roots
+	CertPEM string // client certificate for X.509 authentication
+	KeyPEM  string // private key matching CertPEM
+}
+
+// IsZero reports whether no TLS material was supplied
+func (o *TLSOptions) IsZero() bool {
+	return o == nil || (o.CAPEM == "" && o.CertPEM == "" && o.KeyPEM == "")
+}
+
+// Fingerprint identifies the material so clients with different TLS settings
+// for the same URI are cached separately
+func (o *TLSOptions) Fingerprint() string {
+	if o.IsZero() {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(o.CAPEM + "\x00" + o.CertPEM + "\x00" + o.KeyPEM))
+	return hex.EncodeToString(sum[:])
+}
+
+// BuildTLSConfig validates the supplied material and returns the tls.Config
+// used for the connection. Nil options yield the default configuration.
+func BuildTLSConfig(opts *TLSOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: false,
+		MinVersion:         tls.VersionTLS12,
+		MaxVersion:         tls.VersionTLS13,
+	}
+	if opts.IsZero() {
+		return tlsConfig, nil
+	}
+
+	if opts.CAPEM != "" {
+		pool, err := parseCABundle(opts.CAPEM)
+		if err != nil {
+			return nil, fmt.Errorf("%w: CA bundle: %v", ErrInvalidTLSOptions, err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.CertPEM != "" || opts.KeyPEM != "" {
+		if opts.CertPEM == "" {
+			return nil, fmt.Errorf("%w: client key provided without a client certificate", ErrInvalidTLSOptions)
+		}
+		if opts.KeyPEM == "" {
+			return nil, fmt.Errorf("%w: client certificate provided without a private key", ErrInvalidTLSOptions)
+		}
+
+		cert, err := tls.X509KeyPair([]byte(opts.CertPEM), []byte(opts.KeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("%w: client certificate/key pair: %v", ErrInvalidTLSOptions, err)
+		}
+
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: client certificate: %v", ErrInvalidTLSOptions, err)
+		}
+		now := time.Now()
+		if now.After(leaf.NotAfter) {
+			return nil, fmt.Errorf("%w: client certificate expired on %s", ErrInvalidTLSOptions, leaf.NotAfter.Format(time.RFC3339))
+		}
+		if now.Before(leaf.NotBefore) {
+			return nil, fmt.Errorf("%w: client certificate is not valid until %s", ErrInvalidTLSOptions, leaf.NotBefore.Format(time.RFC3339))
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// parseCABundle parses every certificate in a PEM bundle
+func parseCABundle(bundle string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	rest := []byte(bundle)
+	count := 0
+
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("certificate %d: %v", count+1, err)
+		}
+		pool.AddCert(cert)
+		count++
+	}
+
+	if count == 0 {
+		return nil, errors.New("no PEM encoded certificates found")
+	}
+	return pool, nil
+}
+
+// DescribeTLSError rewrites handshake failures into messages that say which
+// side of the handshake failed. Other errors are returned unchanged.
+func DescribeTLSError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	var reason string
+	switch {
+	case strings.Contains(msg, "certificate signed by unknown authority"):
+		reason = "server certificate is not signed by a trusted CA (check the CA bundle)"
+	case strings.Contains(msg, "certificate is valid for"), strings.Contains(msg, "certificate is not valid for any names"):
+		reason = "server certificate does not match the host name in the URI"
+	case strings.Contains(msg, "certificate has expired or is not yet valid"):
+		reason = "server certificate has expired or is not yet valid"
+	case strings.Contains(msg, "tls: bad certificate"),
+		strings.Contains(msg, "tls: certificate required"),
+		strings.Contains(msg, "tls: unknown certificate authority"),
+		strings.Contains(msg, "tls: expired certificate"):
+		reason = "server rejected the client certificate"
+	case strings.Contains(msg, "first record does not look like a TLS handshake"):
+		reason = "server did not respond with TLS (is TLS enabled on the server?)"
+	case strings.Contains(msg, "tls: protocol version not supported"),
+		strings.Contains(msg, "tls: handshake failure"):
+		reason = "no mutually supported TLS version or cipher suite"
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%w: %s: %v", ErrTLSHandshake, reason, err)
+}
@@ -0,0 +1,155 @@
+package mongodb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed certificate and its key as PEM
+func testCertificate(t *testing.T, notBefore, notAfter time.Time) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "db-access-test"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to encode key: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	now := time.Now()
+	certPEM, keyPEM := testCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+	otherCertPEM, otherKeyPEM := testCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+	expiredCertPEM, expiredKeyPEM := testCertificate(t, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	futureCertPEM, futureKeyPEM := testCertificate(t, now.Add(time.Hour), now.Add(2*time.Hour))
+	garbageCert := "-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"
+
+	tests := []struct {
+		name    string
+		opts    *TLSOptions
+		valid   bool
+		roots   bool
+		clients int
+	}{
+		{"nil options", nil, true, false, 0},
+		{"CA bundle", &TLSOptions{CAPEM: certPEM}, true, true, 0},
+		{"CA bundle with two certificates", &TLSOptions{CAPEM: certPEM + otherCertPEM}, true, true, 0},
+		{"CA bundle skips key blocks", &TLSOptions{CAPEM: keyPEM + certPEM}, true, true, 0},
+		{"client certificate", &TLSOptions{CertPEM: certPEM, KeyPEM: keyPEM}, true, false, 1},
+		{"CA and client certificate", &TLSOptions{CAPEM: otherCertPEM, CertPEM: certPEM, KeyPEM: keyPEM}, true, true, 1},
+		{"CA bundle without certificates", &TLSOptions{CAPEM: "not a pem"}, false, false, 0},
+		{"CA bundle with only a key", &TLSOptions{CAPEM: keyPEM}, false, false, 0},
+		{"malformed CA certificate", &TLSOptions{CAPEM: certPEM + garbageCert}, false, false, 0},
+		{"certificate without key", &TLSOptions{CertPEM: certPEM}, false, false, 0},
+		{"key without certificate", &TLSOptions{KeyPEM: keyPEM}, false, false, 0},
+		{"mismatched key", &TLSOptions{CertPEM: certPEM, KeyPEM: otherKeyPEM}, false, false, 0},
+		{"expired certificate", &TLSOptions{CertPEM: expiredCertPEM, KeyPEM: expiredKeyPEM}, false, false, 0},
+		{"certificate not yet valid", &TLSOptions{CertPEM: futureCertPEM, KeyPEM: futureKeyPEM}, false, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := BuildTLSConfig(tt.opts)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidTLSOptions) {
+					t.Errorf("BuildTLSConfig error = %v, want ErrInvalidTLSOptions", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildTLSConfig returned error: %v", err)
+			}
+
+			if config.InsecureSkipVerify {
+				t.Error("BuildTLSConfig disabled certificate verification")
+			}
+			if config.MinVersion != tls.VersionTLS12 {
+				t.Errorf("MinVersion = %x, want TLS 1.2", config.MinVersion)
+			}
+			if (config.RootCAs != nil) != tt.roots {
+				t.Errorf("RootCAs set = %v, want %v", config.RootCAs != nil, tt.roots)
+			}
+			if len(config.Certificates) != tt.clients {
+				t.Errorf("len(Certificates) = %d, want %d", len(config.Certificates), tt.clients)
+			}
+		})
+	}
+}
+
+func TestTLSOptionsFingerprint(t *testing.T) {
+	var none *TLSOptions
+	if none.Fingerprint() != "" || (&TLSOptions{}).Fingerprint() != "" {
+		t.Error("empty options should have no fingerprint")
+	}
+
+	a := &TLSOptions{CAPEM: "a", CertPEM: "b"}
+	b := &TLSOptions{CAPEM: "ab"}
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("different material produced the same fingerprint")
+	}
+	if a.Fingerprint() != (&TLSOptions{CAPEM: "a", CertPEM: "b"}).Fingerprint() {
+		t.Error("identical material produced different fingerprints")
+	}
+}
+
+func TestDescribeTLSError(t *testing.T) {
+	tests := []struct {
+		message   string
+		handshake bool
+	}{
+		{"x509: certificate signed by unknown authority", true},
+		{"x509: certificate is valid for a.example.com, not b.example.com", true},
+		{"x509: certificate has expired or is not yet valid: current time", true},
+		{"remote error: tls: bad certificate", true},
+		{"remote error: tls: certificate required", true},
+		{"tls: first record does not look like a TLS handshake", true},
+		{"remote error: tls: protocol version not supported", true},
+		{"connection refused", false},
+		{"server selection timeout", false},
+	}
+
+	for _, tt := range tests {
+		original := errors.New(tt.message)
+		err := DescribeTLSError(original)
+		if got := errors.Is(err, ErrTLSHandshake); got != tt.handshake {
+			t.Errorf("DescribeTLSError(%q) = %v, handshake %v, want %v", tt.message, err, got, tt.handshake)
+		}
+		if !tt.handshake && err != original {
+			t.Errorf("DescribeTLSError(%q) changed a non-TLS error to %v", tt.message, err)
+		}
+	}
+
+	if DescribeTLSError(nil) != nil {
+		t.Error("DescribeTLSError(nil) should be nil")
+	}
+}
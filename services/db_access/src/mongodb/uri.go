@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// uriParts is a MongoDB connection string split into its components
+type uriParts struct {
+	Scheme   string   // mongodb or mongodb+srv, lower case
+	UserInfo string   // user:password@ including the trailing @, if any
+	Hosts    []string // host[:port] entries in the order they appear
+	Path     string   // /database, without a trailing slash
+	RawQuery string   // options without the leading ?
+}
+
+// parseURIParts splits a connection string without validating options. The
+// standard library URL parser rejects multi-host authorities, hence the
+// manual split.
+func parseURIParts(rawURI string) (*uriParts, error) {
+	uri := strings.TrimSpace(rawURI)
+	if uri == "" {
+		return nil, fmt.Errorf("MongoDB URI cannot be empty")
+	}
+
+	schemeEnd := strings.Index(uri, "://")
+	if schemeEnd < 0 {
+		return nil, fmt.Errorf("invalid MongoDB URI: missing scheme")
+	}
+	scheme := strings.ToLower(uri[:schemeEnd])
+	if scheme != "mongodb" && scheme != "mongodb+srv" {
+		return nil, fmt.Errorf("invalid MongoDB URI: unsupported scheme %q", scheme)
+	}
+	rest := uri[schemeEnd+3:]
+
+	// Split authority (userinfo@hosts) from the optional /database?options part
+	authority, tail := rest, ""
+	if idx := strings.IndexAny(rest, "/?"); idx >= 0 {
+		authority, tail = rest[:idx], rest[idx:]
+	}
+
+	userInfo, hostList := "", authority
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		userInfo, hostList = authority[:at+1], authority[at+1:]
+	}
+	if hostList == "" {
+		return nil, fmt.Errorf("invalid MongoDB URI: missing host")
+	}
+
+	hosts := strings.Split(hostList, ",")
+	for i, host := range hosts {
+		hosts[i] = strings.TrimSpace(host)
+	}
+
+	path, rawQuery := tail, ""
+	if idx := strings.Index(tail, "?"); idx >= 0 {
+		path, rawQuery = tail[:idx], tail[idx+1:]
+	}
+
+	return &uriParts{
+		Scheme:   scheme,
+		UserInfo: userInfo,
+		Hosts:    hosts,
+		Path:     strings.TrimSuffix(path, "/"),
+		RawQuery: rawQuery,
+	}, nil
+}
+
+// Hostnames returns the host part of every seed entry, without ports
+func (p *uriParts) Hostnames() []string {
+	names := make([]string, 0, len(p.Hosts))
+	for _, host := range p.Hosts {
+		name := host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			name = h
+		}
+		names = append(names, strings.ToLower(strings.Trim(name, "[]")))
+	}
+	return names
+}
+
+// NormalizeURI returns a canonical form of a MongoDB connection string so that
+// URIs which only differ in host order, scheme/host casing or option order map
+// to the same cached client
+func NormalizeURI(rawURI string) (string, error) {
+	parts, err := parseURIParts(rawURI)
+	if err != nil {
+		return "", err
+	}
+
+	hosts := make([]string, len(parts.Hosts))
+	for i, host := range parts.Hosts {
+		hosts[i] = strings.ToLower(host)
+	}
+	sort.Strings(hosts)
+
+	normalized := parts.Scheme + "://" + parts.UserInfo + strings.Join(hosts, ",") + parts.Path
+	if parts.RawQuery != "" {
+		values, err := url.ParseQuery(parts.RawQuery)
+		if err != nil {
+			return "", fmt.Errorf("invalid MongoDB URI options: %v", err)
+		}
+		// Option names are case-insensitive in the connection string spec
+		lowered := url.Values{}
+		for key, vals := range values {
+			lowered[strings.ToLower(key)] = append(lowered[strings.ToLower(key)], vals...)
+		}
+		if parts.Path == "" {
+			normalized += "/"
+		}
+		normalized += "?" + lowered.Encode()
+	}
+
+	return normalized, nil
+}
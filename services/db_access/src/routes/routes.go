@@ -11,9 +11,14 @@ func SetupRoutes(router *gin.Engine) {
 	databaseController := controllers.NewDatabaseController()
 	collectionController := controllers.NewCollectionController()
 	documentController := controllers.NewDocumentController()
+	connectionController := controllers.NewConnectionController()
+	healthController := controllers.NewHealthController()
+	queryController := controllers.NewQueryController()
 
-	// Health check endpoint
+	// Health check endpoints
 	router.GET("/ping", databaseController.Ping)
+	router.GET("/healthz", healthController.Live)
+	router.GET("/readyz", healthController.Ready)
 
 	// Root endpoint with service information
 	router.GET("/", func(c *gin.Context) {
@@ -22,7 +27,9 @@ func SetupRoutes(router *gin.Engine) {
 			"version":     "1.0.0",
 			"description": "MongoDB database access and management service with MVC architecture",
 			"endpoints": gin.H{
-				"health": "GET /ping",
+				"health":    "GET /ping",
+				"liveness":  "GET /healthz",
+				"readiness": "GET /readyz",
 				"database": gin.H{
 					"allocate": "POST /allocate",
 					"info":     "GET /db/:db/info",
@@ -34,18 +41,33 @@ func SetupRoutes(router *gin.Engine) {
 					"sample_data":   "GET /sample-data/:db/:collection",
 					"analyze_docs":  "POST /analyze-documents",
 				},
+				"connections": gin.H{
+					"create": "POST /connections",
+					"delete": "DELETE /connections/:id",
+				},
 				"method3": gin.H{
-					"schema_analysis": "POST /method3/schema-analysis",
-					"data_insert":     "POST /method3/data-insert",
-					"data_get":        "POST /method3/data-get",
-					"data_delete":     "POST /method3/data-delete",
+					"schema_analysis":  "POST /method3/schema-analysis",
+					"data_insert":      "POST /method3/data-insert",
+					"data_insert_many": "POST /method3/data-insert-many",
+					"data_get":         "POST /method3/data-get",
+					"data_delete":      "POST /method3/data-delete",
 				},
 				"documents": gin.H{
-					"create":   "POST /entry/:db/:collection",
-					"read":     "GET /entries/:db/:collection",
-					"read_one": "GET /entry/:db/:collection/:id",
-					"update":   "PUT /entry/:db/:collection/:id",
-					"delete":   "DELETE /entry/:db/:collection/:id",
+					"create":      "POST /entry/:db/:collection",
+					"create_many": "POST /entries/:db/:collection",
+					"update_many": "POST /entries/:db/:collection/update-many",
+					"delete_many": "POST /entries/:db/:collection/delete-many",
+					"read":        "GET /entries/:db/:collection",
+					"read_one":    "GET /entry/:db/:collection/:id",
+					"update":      "PUT /entry/:db/:collection/:id",
+					"patch":       "PATCH /entry/:db/:collection/:id",
+					"delete":      "DELETE /entry/:db/:collection/:id",
+				},
+				"queries": gin.H{
+					"search":    "GET /search/:db/:collection?q=",
+					"aggregate": "POST /aggregate/:db/:collection",
+					"distinct":  "GET /distinct/:db/:collection?fields=",
+					"count":     "GET /count/:db/:collection",
 				},
 			},
 		})
@@ -64,9 +86,15 @@ func SetupRoutes(router *gin.Engine) {
 	router.GET("/sample-data/:db/:collection", collectionController.GetSampleData)
 	router.POST("/analyze-documents", collectionController.AnalyzeDocuments)
 
+	// === CONNECTION HANDLES ===
+	// Register a MongoDB URI once and refer to it by connection_id afterwards
+	router.POST("/connections", connectionController.CreateConnection)
+	router.DELETE("/connections/:id", connectionController.DeleteConnection)
+
 	// Method 3: External MongoDB URI operations
 	router.POST("/method3/schema-analysis", collectionController.Method3SchemaAnalysis)
 	router.POST("/method3/data-insert", collectionController.Method3DataInsert)
+	router.POST("/method3/data-insert-many", collectionController.Method3DataInsertMany)
 	router.POST("/method3/data-get", collectionController.Method3DataGet)
 	router.POST("/method3/data-delete", collectionController.Method3DataDelete)
 	router.POST("/method3/add-schema-fields", collectionController.Method3AddSchemaFields)
@@ -75,15 +103,30 @@ func SetupRoutes(router *gin.Engine) {
 	// === DOCUMENT OPERATIONS ===
 	// CRUD operations for documents
 	router.POST("/entry/:db/:collection", documentController.CreateEntry)
+	router.POST("/entries/:db/:collection", documentController.CreateEntries)
+	router.POST("/entries/:db/:collection/update-many", documentController.UpdateEntries)
+	router.POST("/entries/:db/:collection/delete-many", documentController.DeleteEntries)
 	router.GET("/entries/:db/:collection", documentController.GetCollectionEntries)
 	router.GET("/entry/:db/:collection/:id", documentController.GetEntry)
 	router.PUT("/entry/:db/:collection/:id", documentController.UpdateEntry)
+	router.PATCH("/entry/:db/:collection/:id", documentController.PatchEntry)
 	router.DELETE("/entry/:db/:collection/:id", documentController.DeleteEntry)
 
+	// === QUERY OPERATIONS ===
+	// Read-only search and analytics over a collection
+	router.GET("/search/:db/:collection", queryController.Search)
+	router.POST("/aggregate/:db/:collection", queryController.Aggregate)
+	router.GET("/distinct/:db/:collection", queryController.DistinctValues)
+	router.GET("/count/:db/:collection", queryController.Count)
+
 	// === API VERSION 1 ROUTES ===
 	// Versioned API endpoints for future compatibility
 	v1 := router.Group("/api/v1")
 	{
+		// Connection handles
+		v1.POST("/connections", connectionController.CreateConnection)
+		v1.DELETE("/connections/:id", connectionController.DeleteConnection)
+
 		// Database operations
 		v1.POST("/database/allocate", databaseController.AllocateDatabase)
 		v1.GET("/database/:db", databaseController.GetDatabaseInfo)
@@ -97,9 +140,19 @@ func SetupRoutes(router *gin.Engine) {
 
 		// Document operations
 		v1.POST("/document/:db/:collection", documentController.CreateEntry)
+		v1.POST("/documents/:db/:collection", documentController.CreateEntries)
+		v1.POST("/documents/:db/:collection/update-many", documentController.UpdateEntries)
+		v1.POST("/documents/:db/:collection/delete-many", documentController.DeleteEntries)
 		v1.GET("/documents/:db/:collection", documentController.GetCollectionEntries)
 		v1.GET("/document/:db/:collection/:id", documentController.GetEntry)
 		v1.PUT("/document/:db/:collection/:id", documentController.UpdateEntry)
+		v1.PATCH("/document/:db/:collection/:id", documentController.PatchEntry)
 		v1.DELETE("/document/:db/:collection/:id", documentController.DeleteEntry)
+
+		// Query operations
+		v1.GET("/documents/:db/:collection/search", queryController.Search)
+		v1.POST("/collection/:db/:collection/aggregate", queryController.Aggregate)
+		v1.GET("/collection/:db/:collection/distinct", queryController.DistinctValues)
+		v1.GET("/collection/:db/:collection/count", queryController.Count)
 	}
 }
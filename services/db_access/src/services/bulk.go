@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Bulk insert limits
+const (
+	maxBulkInsertDocuments = 10000
+	defaultInsertBatchSize = 500
+	maxInsertBatchSize     = 1000
+)
+
+// Per-document outcomes in BulkInsertResult.Status
+const (
+	bulkStatusInserted = "inserted"
+	bulkStatusFailed   = "failed"
+	bulkStatusSkipped  = "skipped" // not attempted after an ordered insert stopped
+)
+
+// insertManyFunc matches (*mongo.Collection).InsertMany
+type insertManyFunc func(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+
+// bulkInsert inserts documents in InsertMany batches and reports the outcome
+// of every input index. Ordered inserts stop at the first failure; unordered
+// inserts attempt every document.
+func bulkInsert(ctx context.Context, collection *mongo.Collection, dbName, collectionName string, req models.BulkInsertRequest) (*models.BulkInsertResponse, error) {
+	return insertBatches(ctx, collection.InsertMany, dbName, collectionName, req)
+}
+
+// insertBatches does the work of bulkInsert against any InsertMany
+func insertBatches(ctx context.Context, insertMany insertManyFunc, dbName, collectionName string, req models.BulkInsertRequest) (*models.BulkInsertResponse, error) {
+	if len(req.Documents) == 0 || len(req.Documents) > maxBulkInsertDocuments {
+		return nil, fmt.Errorf("%w: documents must hold between 1 and %d documents", ErrInvalidQuery, maxBulkInsertDocuments)
+	}
+	batchSize := req.BatchSize
+	if batchSize < 0 || batchSize > maxInsertBatchSize {
+		return nil, fmt.Errorf("%w: batch_size must be between 1 and %d", ErrInvalidQuery, maxInsertBatchSize)
+	}
+	if batchSize == 0 {
+		batchSize = defaultInsertBatchSize
+	}
+	ordered := req.Ordered == nil || *req.Ordered
+
+	results := make([]models.BulkInsertResult, len(req.Documents))
+	for i := range results {
+		results[i] = models.BulkInsertResult{Index: i, Status: bulkStatusSkipped}
+	}
+	response := &models.BulkInsertResponse{
+		Database:   dbName,
+		Collection: collectionName,
+		Ordered:    ordered,
+		Results:    results,
+	}
+
+	var batch []interface{}
+	var batchIndexes []int
+	// flush inserts the pending batch and reports whether to keep going
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		defer func() { batch, batchIndexes = nil, nil }()
+
+		_, err := insertMany(ctx, batch, options.InsertMany().SetOrdered(ordered))
+
+		failed := make(map[int]string)
+		var writeErr mongo.BulkWriteException
+		if errors.As(err, &writeErr) {
+			for _, we := range writeErr.WriteErrors {
+				failed[we.Index] = we.Message
+			}
+			if writeErr.WriteConcernError != nil {
+				response.WriteConcernError = writeErr.WriteConcernError.Message
+			}
+		} else if err != nil {
+			// Not a per-document failure, so the batch outcome is unknown
+			for _, i := range batchIndexes {
+				results[i].Status = bulkStatusFailed
+				results[i].Error = err.Error()
+			}
+			return false
+		}
+
+		stoppedAt := len(batch)
+		for j, i := range batchIndexes {
+			if message, ok := failed[j]; ok {
+				results[i].Status = bulkStatusFailed
+				results[i].Error = message
+				if ordered && j < stoppedAt {
+					stoppedAt = j
+				}
+				continue
+			}
+			if j > stoppedAt {
+				continue // never attempted, stays skipped
+			}
+			results[i].Status = bulkStatusInserted
+			results[i].InsertedID = batch[j].(map[string]interface{})["_id"]
+		}
+		return len(failed) == 0 || !ordered
+	}
+
+	stopped := false
+	for i, doc := range req.Documents {
+		if !utils.ValidateDocumentData(doc) {
+			if ordered && !flush() {
+				stopped = true
+				break
+			}
+			results[i].Status = bulkStatusFailed
+			results[i].Error = "document is empty"
+			if ordered {
+				stopped = true
+				break
+			}
+			continue
+		}
+
+		// Assign ids up front so each input index maps to its inserted id
+		sanitized := utils.SanitizeDocumentData(doc)
+		if _, ok := sanitized["_id"]; !ok {
+			sanitized["_id"] = primitive.NewObjectID()
+		}
+		stampVersion(sanitized)
+		batch = append(batch, sanitized)
+		batchIndexes = append(batchIndexes, i)
+
+		if len(batch) == batchSize && !flush() {
+			stopped = true
+			break
+		}
+	}
+	if !stopped {
+		flush()
+	}
+
+	for _, result := range results {
+		switch result.Status {
+		case bulkStatusInserted:
+			response.InsertedCount++
+		case bulkStatusFailed:
+			response.FailedCount++
+		default:
+			response.SkippedCount++
+		}
+	}
+	response.Message = fmt.Sprintf("Inserted %d of %d documents", response.InsertedCount, len(req.Documents))
+	return response, nil
+}
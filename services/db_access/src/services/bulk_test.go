@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeInserter stands in for InsertMany. Documents whose "row" is in
+// duplicates fail with a write error; batch number failBatch (1-based) fails
+// outright with batchErr.
+type fakeInserter struct {
+	duplicates   map[int]bool
+	failBatch    int
+	batchErr     error
+	writeConcern string
+	batches      [][]int
+}
+
+func (f *fakeInserter) insertMany(_ context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	ordered := opts[0].Ordered == nil || *opts[0].Ordered
+
+	rows := make([]int, len(documents))
+	for i, doc := range documents {
+		rows[i] = doc.(map[string]interface{})["row"].(int)
+	}
+	f.batches = append(f.batches, rows)
+	if len(f.batches) == f.failBatch {
+		return nil, f.batchErr
+	}
+
+	var exception mongo.BulkWriteException
+	for i, row := range rows {
+		if f.duplicates[row] {
+			exception.WriteErrors = append(exception.WriteErrors, mongo.BulkWriteError{
+				WriteError: mongo.WriteError{Index: i, Code: 11000, Message: fmt.Sprintf("E11000 duplicate key row %d", row)},
+			})
+			if ordered {
+				break
+			}
+		}
+	}
+	if f.writeConcern != "" {
+		exception.WriteConcernError = &mongo.WriteConcernError{Message: f.writeConcern}
+	}
+	if len(exception.WriteErrors) > 0 || exception.WriteConcernError != nil {
+		return &mongo.InsertManyResult{}, exception
+	}
+	return &mongo.InsertManyResult{}, nil
+}
+
+// testRows returns n documents numbered by a "row" field; rows in empty are
+// left empty
+func testRows(n int, empty ...int) []map[string]interface{} {
+	docs := make([]map[string]interface{}, n)
+	for i := range docs {
+		docs[i] = map[string]interface{}{"row": i}
+	}
+	for _, i := range empty {
+		docs[i] = map[string]interface{}{}
+	}
+	return docs
+}
+
+func TestInsertBatchesResults(t *testing.T) {
+	unordered := false
+	networkErr := errors.New("connection reset")
+
+	tests := []struct {
+		name     string
+		docs     []map[string]interface{}
+		ordered  *bool
+		inserter *fakeInserter
+		want     []string // status per input index: i(nserted), f(ailed), s(kipped)
+		batches  [][]int
+	}{
+		{
+			name:     "all inserted",
+			docs:     testRows(5),
+			inserter: &fakeInserter{},
+			want:     []string{"i", "i", "i", "i", "i"},
+			batches:  [][]int{{0, 1}, {2, 3}, {4}},
+		},
+		{
+			name:     "ordered stops at the duplicate",
+			docs:     testRows(5),
+			inserter: &fakeInserter{duplicates: map[int]bool{3: true, 4: true}},
+			want:     []string{"i", "i", "i", "f", "s"},
+			batches:  [][]int{{0, 1}, {2, 3}},
+		},
+		{
+			name:     "ordered duplicate first in batch",
+			docs:     testRows(4),
+			inserter: &fakeInserter{duplicates: map[int]bool{0: true}},
+			want:     []string{"f", "s", "s", "s"},
+			batches:  [][]int{{0, 1}},
+		},
+		{
+			name:     "unordered reports every duplicate",
+			docs:     testRows(5),
+			ordered:  &unordered,
+			inserter: &fakeInserter{duplicates: map[int]bool{1: true, 3: true}},
+			want:     []string{"i", "f", "i", "f", "i"},
+			batches:  [][]int{{0, 1}, {2, 3}, {4}},
+		},
+		{
+			name:     "ordered empty document flushes earlier rows first",
+			docs:     testRows(5, 1),
+			inserter: &fakeInserter{},
+			want:     []string{"i", "f", "s", "s", "s"},
+			batches:  [][]int{{0}},
+		},
+		{
+			name:     "unordered empty document",
+			docs:     testRows(4, 2),
+			ordered:  &unordered,
+			inserter: &fakeInserter{},
+			want:     []string{"i", "i", "f", "i"},
+			batches:  [][]int{{0, 1}, {3}},
+		},
+		{
+			name:     "batch error fails the whole batch and stops",
+			docs:     testRows(6),
+			ordered:  &unordered,
+			inserter: &fakeInserter{failBatch: 2, batchErr: networkErr},
+			want:     []string{"i", "i", "f", "f", "s", "s"},
+			batches:  [][]int{{0, 1}, {2, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.BulkInsertRequest{Documents: tt.docs, Ordered: tt.ordered, BatchSize: 2}
+			response, err := insertBatches(context.Background(), tt.inserter.insertMany, "shop", "orders", req)
+			if err != nil {
+				t.Fatalf("insertBatches returned error: %v", err)
+			}
+
+			statuses := make([]string, len(response.Results))
+			counts := map[string]int{}
+			for i, result := range response.Results {
+				if result.Index != i {
+					t.Errorf("result %d has index %d", i, result.Index)
+				}
+				statuses[i] = result.Status[:1]
+				counts[result.Status]++
+
+				switch result.Status {
+				case bulkStatusInserted:
+					if result.InsertedID == nil || result.Error != "" {
+						t.Errorf("inserted result %d = %+v, want an id and no error", i, result)
+					}
+				case bulkStatusFailed:
+					if result.InsertedID != nil || result.Error == "" {
+						t.Errorf("failed result %d = %+v, want an error and no id", i, result)
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(statuses, tt.want) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.want)
+			}
+			if !reflect.DeepEqual(tt.inserter.batches, tt.batches) {
+				t.Errorf("batches = %v, want %v", tt.inserter.batches, tt.batches)
+			}
+			if response.InsertedCount != counts[bulkStatusInserted] || response.FailedCount != counts[bulkStatusFailed] || response.SkippedCount != counts[bulkStatusSkipped] {
+				t.Errorf("counts = %d/%d/%d, want %v", response.InsertedCount, response.FailedCount, response.SkippedCount, counts)
+			}
+		})
+	}
+}
+
+func TestInsertBatchesMapsErrorsToInputRows(t *testing.T) {
+	unordered := false
+	inserter := &fakeInserter{duplicates: map[int]bool{3: true}}
+	req := models.BulkInsertRequest{Documents: testRows(5), Ordered: &unordered, BatchSize: 2}
+
+	response, err := insertBatches(context.Background(), inserter.insertMany, "shop", "orders", req)
+	if err != nil {
+		t.Fatalf("insertBatches returned error: %v", err)
+	}
+	// Row 3 is index 1 of the second batch
+	if got := response.Results[3].Error; got != "E11000 duplicate key row 3" {
+		t.Errorf("row 3 error = %q", got)
+	}
+}
+
+func TestInsertBatchesKeepsIDs(t *testing.T) {
+	docs := testRows(2)
+	docs[0]["_id"] = "custom"
+
+	response, err := insertBatches(context.Background(), (&fakeInserter{}).insertMany, "shop", "orders", models.BulkInsertRequest{Documents: docs})
+	if err != nil {
+		t.Fatalf("insertBatches returned error: %v", err)
+	}
+	if response.Results[0].InsertedID != "custom" {
+		t.Errorf("inserted id = %v, want the supplied _id", response.Results[0].InsertedID)
+	}
+	if _, ok := response.Results[1].InsertedID.(primitive.ObjectID); !ok {
+		t.Errorf("inserted id = %T, want a generated ObjectID", response.Results[1].InsertedID)
+	}
+}
+
+func TestInsertBatchesWriteConcernError(t *testing.T) {
+	inserter := &fakeInserter{writeConcern: "waiting for replication timed out"}
+	response, err := insertBatches(context.Background(), inserter.insertMany, "shop", "orders", models.BulkInsertRequest{Documents: testRows(2)})
+	if err != nil {
+		t.Fatalf("insertBatches returned error: %v", err)
+	}
+	if response.WriteConcernError != "waiting for replication timed out" || response.InsertedCount != 2 {
+		t.Errorf("response = %+v", response)
+	}
+}
+
+func TestInsertBatchesRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.BulkInsertRequest
+	}{
+		{"no documents", models.BulkInsertRequest{}},
+		{"too many documents", models.BulkInsertRequest{Documents: testRows(maxBulkInsertDocuments + 1)}},
+		{"negative batch size", models.BulkInsertRequest{Documents: testRows(1), BatchSize: -1}},
+		{"batch size too large", models.BulkInsertRequest{Documents: testRows(1), BatchSize: maxInsertBatchSize + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inserter := &fakeInserter{}
+			if _, err := insertBatches(context.Background(), inserter.insertMany, "shop", "orders", tt.req); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("insertBatches error = %v, want ErrInvalidQuery", err)
+			}
+			if len(inserter.batches) != 0 {
+				t.Errorf("insertBatches called InsertMany for an invalid request")
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/configs"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Defaults when BULK_WRITE_LIMIT and BULK_CONFIRM_TTL are not configured
+const (
+	defaultBulkWriteLimit = 1000
+	defaultBulkConfirmTTL = 5 * time.Minute
+)
+
+// IDs of matched documents returned by a dry run
+const bulkSampleSize = 20
+
+// Bulk write operations
+const (
+	bulkOpUpdate = "update"
+	bulkOpDelete = "delete"
+)
+
+// bulkUpdateOperators are the update operators update-many accepts
+var bulkUpdateOperators = map[string]bool{
+	"$set":   true,
+	"$unset": true,
+	"$inc":   true,
+}
+
+// confirmToken is the signed payload behind a dry run's confirm_token. It
+// binds the confirmation to the exact operation, collection, filter and
+// update that were previewed.
+type confirmToken struct {
+	Operation   string `json:"op"`
+	Namespace   string `json:"ns"`
+	RequestHash string `json:"rh"`
+	IDHash      string `json:"ids"` // hash of the previewed _id set
+	Nonce       string `json:"n"`   // makes the token single use
+	ExpiresAt   int64  `json:"exp"`
+}
+
+// usedConfirmTokens remembers the nonces of accepted tokens until they expire
+var usedConfirmTokens = &nonceSet{used: make(map[string]int64)}
+
+// nonceSet records single-use nonces of this process. Tokens are only
+// accepted by the instance that remembers them, which matches how connection
+// handles are kept.
+type nonceSet struct {
+	mu   sync.Mutex
+	used map[string]int64 // nonce to expiry (unix seconds)
+}
+
+// claim marks nonce as used and reports whether it was unused
+func (n *nonceSet) claim(nonce string, expiresAt int64) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now().Unix()
+	for key, exp := range n.used {
+		if exp < now {
+			delete(n.used, key)
+		}
+	}
+
+	if nonce == "" {
+		return false
+	}
+	if _, used := n.used[nonce]; used {
+		return false
+	}
+	n.used[nonce] = expiresAt
+	return true
+}
+
+// UpdateMany updates every document matching a filter. The first call is a
+// dry run; the update runs when the returned confirm token is echoed back.
+func (s *DocumentService) UpdateMany(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName string, req models.BulkWriteRequest) (*models.BulkWriteResponse, error) {
+	return bulkWrite(ctx, conn, consistency, dbName, collectionName, bulkOpUpdate, req)
+}
+
+// DeleteMany deletes every document matching a filter, with the same dry run
+// and confirmation as UpdateMany
+func (s *DocumentService) DeleteMany(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName string, req models.BulkWriteRequest) (*models.BulkWriteResponse, error) {
+	return bulkWrite(ctx, conn, consistency, dbName, collectionName, bulkOpDelete, req)
+}
+
+func bulkWrite(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, operation string, req models.BulkWriteRequest) (*models.BulkWriteResponse, error) {
+	if !utils.IsValidDBName(dbName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
+	}
+
+	if !utils.IsValidCollectionName(collectionName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
+	}
+
+	filterParam, err := filterString(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var update bson.D
+	if operation == bulkOpUpdate {
+		if update, err = utils.ParseUpdate(req.Update, bulkUpdateOperators); err != nil {
+			return nil, err
+		}
+		if autoVersion() {
+			if update, err = withVersionBump(update); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.LongTimeout)
+	defer cancel()
+
+	filter, err := buildFilter(ctx, collection, filterParam)
+	if err != nil {
+		return nil, err
+	}
+	if len(filter) == 0 {
+		return nil, fmt.Errorf("%w: a filter is required to %s many documents", ErrInvalidQuery, operation)
+	}
+
+	limit := bulkWriteLimit()
+	namespace := dbName + "." + collectionName
+	requestHash := bulkRequestHash(operation, filterParam, string(req.Update))
+	response := &models.BulkWriteResponse{
+		Database:   dbName,
+		Collection: collectionName,
+		Operation:  operation,
+		Limit:      limit,
+		Code:       0,
+	}
+
+	if req.ConfirmToken == "" {
+		return dryRunBulkWrite(ctx, collection, filter, namespace, requestHash, response)
+	}
+
+	token, err := verifyConfirmToken(req.ConfirmToken, operation, namespace, requestHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the documents that were previewed may be changed: the current
+	// matches must be exactly that set, and the write is restricted to it so
+	// documents that start matching afterwards are left alone
+	ids, err := matchedIDs(ctx, collection, filter, int64(limit)+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > limit {
+		return nil, fmt.Errorf("%w: more than %d documents match, at most %d may be changed at once", ErrWriteLimitExceeded, limit, limit)
+	}
+	if idSetHash(ids) != token.IDHash {
+		return nil, fmt.Errorf("%w: the matching documents changed since the dry run, run it again", ErrInvalidConfirmation)
+	}
+	if !usedConfirmTokens.claim(token.Nonce, token.ExpiresAt) {
+		return nil, fmt.Errorf("%w: token has already been used, run the dry run again", ErrInvalidConfirmation)
+	}
+	bounded := bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$in": ids}}}}
+
+	switch operation {
+	case bulkOpUpdate:
+		result, err := collection.UpdateMany(ctx, bounded, update)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update documents: %w", err)
+		}
+		response.MatchedCount = result.MatchedCount
+		response.ModifiedCount = result.ModifiedCount
+		response.Message = fmt.Sprintf("Updated %d documents", result.ModifiedCount)
+	case bulkOpDelete:
+		result, err := collection.DeleteMany(ctx, bounded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete documents: %w", err)
+		}
+		response.MatchedCount = int64(len(ids))
+		response.DeletedCount = result.DeletedCount
+		response.Message = fmt.Sprintf("Deleted %d documents", result.DeletedCount)
+	}
+
+	return response, nil
+}
+
+// dryRunBulkWrite fills in a preview of the matched documents and, when the
+// write is within the limit, a confirm token bound to exactly those documents
+func dryRunBulkWrite(ctx context.Context, collection *mongo.Collection, filter bson.M, namespace, requestHash string, response *models.BulkWriteResponse) (*models.BulkWriteResponse, error) {
+	response.DryRun = true
+
+	matched, err := collection.CountDocuments(ctx, filter, options.Count().SetMaxTime(filteredReadMaxTime()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count matching documents: %w", err)
+	}
+	response.MatchedCount = matched
+
+	switch {
+	case matched == 0:
+		response.SampleIDs = []interface{}{}
+		response.Message = "No documents match the filter"
+		return response, nil
+	case matched > int64(response.Limit):
+		if response.SampleIDs, err = matchedIDs(ctx, collection, filter, bulkSampleSize); err != nil {
+			return nil, err
+		}
+		response.Message = fmt.Sprintf("%d documents match, more than the limit of %d; narrow the filter", matched, response.Limit)
+		return response, nil
+	}
+
+	ids, err := matchedIDs(ctx, collection, filter, int64(response.Limit)+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > response.Limit {
+		response.SampleIDs = sampleIDs(ids)
+		response.Message = fmt.Sprintf("more than %d documents match; narrow the filter", response.Limit)
+		return response, nil
+	}
+	response.MatchedCount = int64(len(ids))
+	response.SampleIDs = sampleIDs(ids)
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate confirm token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(bulkConfirmTTL()).UTC().Truncate(time.Second)
+	token, err := utils.SignPayload(confirmToken{
+		Operation:   response.Operation,
+		Namespace:   namespace,
+		RequestHash: requestHash,
+		IDHash:      idSetHash(ids),
+		Nonce:       hex.EncodeToString(nonce),
+		ExpiresAt:   expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	response.ConfirmToken = token
+	response.ExpiresAt = &expiresAt
+	response.Message = fmt.Sprintf("%d documents would be affected; repeat the request with confirm_token to %s them", response.MatchedCount, response.Operation)
+	return response, nil
+}
+
+// verifyConfirmToken checks the signature and expiry of a confirm token and
+// that it was issued for this operation, collection, filter and update
+func verifyConfirmToken(raw, operation, namespace, requestHash string) (*confirmToken, error) {
+	var token confirmToken
+	if err := utils.VerifyPayload(raw, &token); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfirmation, err)
+	}
+	if token.Operation != operation || token.Namespace != namespace || token.RequestHash != requestHash {
+		return nil, fmt.Errorf("%w: token was issued for a different operation, collection, filter or update", ErrInvalidConfirmation)
+	}
+	if time.Now().Unix() > token.ExpiresAt {
+		return nil, fmt.Errorf("%w: token expired, run the dry run again", ErrInvalidConfirmation)
+	}
+	return &token, nil
+}
+
+// matchedIDs returns the _id of up to max matching documents in _id order
+func matchedIDs(ctx context.Context, collection *mongo.Collection, filter bson.M, max int64) ([]interface{}, error) {
+	findOptions := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(max).
+		SetMaxTime(filteredReadMaxTime())
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read matching documents: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode matching documents: %w", err)
+	}
+	ids := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc["_id"])
+	}
+	return ids, nil
+}
+
+// sampleIDs returns the first bulkSampleSize IDs
+func sampleIDs(ids []interface{}) []interface{} {
+	if len(ids) > bulkSampleSize {
+		return ids[:bulkSampleSize]
+	}
+	return ids
+}
+
+// idSetHash fingerprints an ordered list of document IDs
+func idSetHash(ids []interface{}) string {
+	data, err := bson.Marshal(bson.D{{Key: "ids", Value: ids}})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// bulkRequestHash fingerprints the operation, filter and update a token is for
+func bulkRequestHash(operation, filter, update string) string {
+	sum := sha256.Sum256([]byte(operation + "\x00" + filter + "\x00" + update))
+	return hex.EncodeToString(sum[:16])
+}
+
+func bulkWriteLimit() int {
+	if configs.Env != nil && configs.Env.BulkWriteLimit > 0 {
+		return configs.Env.BulkWriteLimit
+	}
+	return defaultBulkWriteLimit
+}
+
+func bulkConfirmTTL() time.Duration {
+	if configs.Env != nil && configs.Env.BulkConfirmTTL > 0 {
+		return configs.Env.BulkConfirmTTL
+	}
+	return defaultBulkConfirmTTL
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+)
+
+// signTestToken signs a confirm token for update on shop.orders, changed by
+// edit before signing
+func signTestToken(t *testing.T, requestHash string, edit func(*confirmToken)) string {
+	t.Helper()
+
+	token := confirmToken{
+		Operation:   bulkOpUpdate,
+		Namespace:   "shop.orders",
+		RequestHash: requestHash,
+		Nonce:       "n1",
+		ExpiresAt:   time.Now().Add(time.Minute).Unix(),
+	}
+	if edit != nil {
+		edit(&token)
+	}
+	signed, err := utils.SignPayload(token)
+	if err != nil {
+		t.Fatalf("SignPayload returned error: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyConfirmToken(t *testing.T) {
+	requestHash := bulkRequestHash(bulkOpUpdate, "status:stale", `{"$set": {"archived": true}}`)
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", signTestToken(t, requestHash, nil), true},
+		{"garbage", "not-a-token", false},
+		{"unsigned", "eyJvcCI6InVwZGF0ZSJ9.", false},
+		{"other operation", signTestToken(t, requestHash, func(c *confirmToken) { c.Operation = bulkOpDelete }), false},
+		{"other collection", signTestToken(t, requestHash, func(c *confirmToken) { c.Namespace = "shop.users" }), false},
+		{"other filter or update", signTestToken(t, bulkRequestHash(bulkOpUpdate, "status:any", `{"$set": {"archived": true}}`), nil), false},
+		{"expired", signTestToken(t, requestHash, func(c *confirmToken) { c.ExpiresAt = time.Now().Add(-time.Second).Unix() }), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := verifyConfirmToken(tt.token, bulkOpUpdate, "shop.orders", requestHash)
+			if tt.ok {
+				if err != nil || token.Nonce != "n1" {
+					t.Errorf("verifyConfirmToken = %+v, %v", token, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfirmation) {
+				t.Errorf("verifyConfirmToken error = %v, want ErrInvalidConfirmation", err)
+			}
+		})
+	}
+}
+
+func TestBulkRequestHash(t *testing.T) {
+	base := bulkRequestHash(bulkOpUpdate, "status:stale", `{"$set": {"a": 1}}`)
+	if base != bulkRequestHash(bulkOpUpdate, "status:stale", `{"$set": {"a": 1}}`) {
+		t.Error("bulkRequestHash is not stable")
+	}
+
+	for _, other := range []string{
+		bulkRequestHash(bulkOpDelete, "status:stale", `{"$set": {"a": 1}}`),
+		bulkRequestHash(bulkOpUpdate, "status:fresh", `{"$set": {"a": 1}}`),
+		bulkRequestHash(bulkOpUpdate, "status:stale", `{"$set": {"a": 2}}`),
+		bulkRequestHash(bulkOpUpdate, "status:stale\x00", `{"$set": {"a": 1}}`),
+	} {
+		if other == base {
+			t.Errorf("different requests share the hash %s", base)
+		}
+	}
+}
+
+func TestBulkWriteRejectsBeforeConnecting(t *testing.T) {
+	service := NewDocumentService()
+	tests := []struct {
+		name    string
+		db      string
+		op      string
+		req     models.BulkWriteRequest
+		wantErr error
+	}{
+		{"invalid database", "a b", bulkOpDelete, models.BulkWriteRequest{}, ErrInvalidDatabaseName},
+		{"operator outside the allowlist", "shop", bulkOpUpdate, models.BulkWriteRequest{Update: []byte(`{"$rename": {"a": "b"}}`)}, utils.ErrInvalidUpdate},
+		{"missing update", "shop", bulkOpUpdate, models.BulkWriteRequest{}, utils.ErrInvalidUpdate},
+		{"_id change", "shop", bulkOpUpdate, models.BulkWriteRequest{Update: []byte(`{"$set": {"_id": 1}}`)}, utils.ErrInvalidUpdate},
+		{"no connection", "shop", bulkOpDelete, models.BulkWriteRequest{}, ErrConnectionRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.op == bulkOpUpdate {
+				_, err = service.UpdateMany(context.Background(), models.ConnectionRef{}, models.ConsistencyOptions{}, tt.db, "orders", tt.req)
+			} else {
+				_, err = service.DeleteMany(context.Background(), models.ConnectionRef{}, models.ConsistencyOptions{}, tt.db, "orders", tt.req)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNonceSetClaim(t *testing.T) {
+	nonces := &nonceSet{used: make(map[string]int64)}
+	expiresAt := time.Now().Add(time.Minute).Unix()
+
+	if !nonces.claim("a", expiresAt) {
+		t.Error("first claim of a nonce was refused")
+	}
+	if nonces.claim("a", expiresAt) {
+		t.Error("a used nonce was claimed again")
+	}
+	if !nonces.claim("b", expiresAt) {
+		t.Error("claim of a different nonce was refused")
+	}
+	if nonces.claim("", expiresAt) {
+		t.Error("the empty nonce was claimed")
+	}
+
+	// Expired entries are dropped on the next claim
+	nonces.used["old"] = time.Now().Add(-time.Minute).Unix()
+	nonces.claim("c", expiresAt)
+	if _, kept := nonces.used["old"]; kept {
+		t.Error("expired nonce was not pruned")
+	}
+	if !nonces.claim("old", expiresAt) {
+		t.Error("nonce could not be claimed after its entry expired")
+	}
+}
+
+func TestIDSetHash(t *testing.T) {
+	first := idSetHash([]interface{}{int32(1), int32(2)})
+
+	if first == "" || first != idSetHash([]interface{}{int32(1), int32(2)}) {
+		t.Errorf("idSetHash is not stable: %q", first)
+	}
+	if first == idSetHash([]interface{}{int32(1), int32(3)}) {
+		t.Error("different ID sets hash the same")
+	}
+	if first == idSetHash([]interface{}{int32(1)}) {
+		t.Error("a subset hashes the same as the full set")
+	}
+}
+
+func TestSampleIDs(t *testing.T) {
+	ids := make([]interface{}, bulkSampleSize+5)
+	if got := len(sampleIDs(ids)); got != bulkSampleSize {
+		t.Errorf("len(sampleIDs) = %d, want %d", got, bulkSampleSize)
+	}
+	if got := len(sampleIDs(ids[:2])); got != 2 {
+		t.Errorf("len(sampleIDs) = %d, want 2", got)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
 	"github.com/abhidhanve/universal-dashboard/services/db_access/src/mongodb"
@@ -12,22 +13,30 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Collections counted at the same time when listing a database
+const collectionCountWorkers = 8
+
 type CollectionService struct{}
 
 func NewCollectionService() *CollectionService {
 	return &CollectionService{}
 }
 
-// ListCollections retrieves all collections in a database with their document counts
-func (s *CollectionService) ListCollections(dbName string) (*models.CollectionsListResponse, error) {
+// ListCollections retrieves all collections in a database with their document
+// counts, exact or estimated from collection metadata
+func (s *CollectionService) ListCollections(ctx context.Context, conn models.ConnectionRef, dbName string, estimated bool) (*models.CollectionsListResponse, error) {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
-	client := mongodb.GetClient()
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	db := client.Database(dbName)
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
 	defer cancel()
 
 	// Get collection names
@@ -36,52 +45,83 @@ func (s *CollectionService) ListCollections(dbName string) (*models.CollectionsL
 		return nil, fmt.Errorf("failed to list collections: %v", err)
 	}
 
-	// Get collection info with document counts
-	var collectionsInfo []models.CollectionInfo
+	collectionsInfo := []models.CollectionInfo{}
 	for _, collName := range collections {
-		if !utils.IsValidCollectionName(collName) {
-			continue // Skip invalid collection names
-		}
-
-		coll := db.Collection(collName)
-		count, err := coll.CountDocuments(ctx, bson.M{})
-		if err != nil {
-			// Log error but continue with 0 count
-			count = 0
+		if utils.IsValidCollectionName(collName) { // Skip invalid collection names
+			collectionsInfo = append(collectionsInfo, models.CollectionInfo{Name: collName})
 		}
+	}
 
-		collectionsInfo = append(collectionsInfo, models.CollectionInfo{
-			Name:          collName,
-			DocumentCount: count,
-		})
+	// Count concurrently, each collection under its own timeout so one slow
+	// count does not fail the listing
+	sem := make(chan struct{}, collectionCountWorkers)
+	var wg sync.WaitGroup
+	for i := range collectionsInfo {
+		wg.Add(1)
+		go func(info *models.CollectionInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			countCtx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
+			defer cancel()
+
+			coll := db.Collection(info.Name)
+			var count int64
+			var err error
+			if estimated {
+				count, err = coll.EstimatedDocumentCount(countCtx)
+			} else {
+				count, err = coll.CountDocuments(countCtx, bson.M{})
+			}
+			if err != nil {
+				info.DocumentCount = -1
+				info.Error = err.Error()
+				return
+			}
+			info.DocumentCount = count
+		}(&collectionsInfo[i])
 	}
+	wg.Wait()
 
 	response := &models.CollectionsListResponse{
-		Message:     "Collections listed successfully",
-		Database:    dbName,
-		Collections: collectionsInfo,
-		Total:       len(collectionsInfo),
-		Code:        0,
+		Message:         "Collections listed successfully",
+		Database:        dbName,
+		Collections:     collectionsInfo,
+		Total:           len(collectionsInfo),
+		CountsEstimated: estimated,
+		Code:            0,
 	}
 
 	return response, nil
 }
 
 // DetectSchema analyzes a collection and detects its schema structure
-func (s *CollectionService) DetectSchema(dbName, collectionName string) (*models.SchemaDetectionResponse, error) {
+func (s *CollectionService) DetectSchema(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName string) (*models.SchemaDetectionResponse, error) {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
 	if !utils.IsValidCollectionName(collectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", collectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
 	}
 
-	client := mongodb.GetClient()
-	db := client.Database(dbName)
-	collection := db.Collection(collectionName)
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.MediumTimeout)
+	// Schema detection is read heavy, prefer secondaries unless told otherwise
+	if consistency.ReadPreference == "" {
+		consistency.ReadPreference = mongodb.SchemaReadPreference()
+	}
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
 	defer cancel()
 
 	// Get sample documents for schema analysis
@@ -126,13 +166,13 @@ func (s *CollectionService) DetectSchema(dbName, collectionName string) (*models
 }
 
 // GetSampleData retrieves sample documents from a collection
-func (s *CollectionService) GetSampleData(dbName, collectionName string, limit int) (*models.SampleDataResponse, error) {
+func (s *CollectionService) GetSampleData(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName string, limit int) (*models.SampleDataResponse, error) {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
 	if !utils.IsValidCollectionName(collectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", collectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
 	}
 
 	// Validate and normalize limit
@@ -140,11 +180,18 @@ func (s *CollectionService) GetSampleData(dbName, collectionName string, limit i
 		limit = 10
 	}
 
-	client := mongodb.GetClient()
-	db := client.Database(dbName)
-	collection := db.Collection(collectionName)
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
 	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetLimit(int64(limit)))
@@ -171,19 +218,23 @@ func (s *CollectionService) GetSampleData(dbName, collectionName string, limit i
 }
 
 // AnalyzeMultipleCollections analyzes document structures for multiple collections
-func (s *CollectionService) AnalyzeMultipleCollections(req models.DocumentAnalysisRequest) (*models.DocumentAnalysisResponse, error) {
+func (s *CollectionService) AnalyzeMultipleCollections(ctx context.Context, req models.DocumentAnalysisRequest) (*models.DocumentAnalysisResponse, error) {
 	if !utils.IsValidDBName(req.DBName) {
-		return nil, fmt.Errorf("invalid database name: %s", req.DBName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, req.DBName)
 	}
 
 	if len(req.Collections) == 0 {
 		return nil, fmt.Errorf("no collections specified for analysis")
 	}
 
-	client := mongodb.GetClient()
+	client, release, err := acquireClient(req.ConnectionRef)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	db := client.Database(req.DBName)
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.LongTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.LongTimeout)
 	defer cancel()
 
 	results := make(map[string]models.DocumentAnalysisResult)
@@ -205,7 +256,6 @@ func (s *CollectionService) AnalyzeMultipleCollections(req models.DocumentAnalys
 			results[collName] = models.DocumentAnalysisResult{
 				Error: fmt.Sprintf("Failed to query collection: %v", err),
 			}
-			cursor.Close(ctx)
 			continue
 		}
 
@@ -248,27 +298,27 @@ func (s *CollectionService) AnalyzeMultipleCollections(req models.DocumentAnalys
 }
 
 // Method3DetectSchema analyzes a collection schema using external MongoDB URI (Method 3)
-func (s *CollectionService) Method3DetectSchema(req models.Method3SchemaRequest) (*models.SchemaDetectionResponse, error) {
+func (s *CollectionService) Method3DetectSchema(ctx context.Context, req models.Method3SchemaRequest) (*models.SchemaDetectionResponse, error) {
 	// Validate inputs
 	if !utils.IsValidDBName(req.DatabaseName) {
-		return nil, fmt.Errorf("invalid database name: %s", req.DatabaseName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, req.DatabaseName)
 	}
 
 	if !utils.IsValidCollectionName(req.CollectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", req.CollectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, req.CollectionName)
 	}
 
-	// Connect to external MongoDB using provided URI
-	client, err := mongodb.ConnectWithURI(req.MongoURI)
+	// Reuse a pooled client for the referenced connection
+	client, release, err := acquireClient(req.ConnectionRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to external MongoDB: %v", err)
+		return nil, err
 	}
-	defer client.Disconnect(context.Background())
+	defer release()
 
 	// Get the database
 	db := client.Database(req.DatabaseName)
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.MediumTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
 	defer cancel()
 
 	// Check if database exists by listing databases
@@ -323,8 +373,17 @@ func (s *CollectionService) Method3DetectSchema(req models.Method3SchemaRequest)
 		}, nil
 	}
 
+	// Schema detection samples heavily, so by default it may run on secondaries
+	consistency := req.ConsistencyOptions
+	if consistency.ReadPreference == "" {
+		consistency.ReadPreference = mongodb.SchemaReadPreference()
+	}
+
 	// Get the collection
-	collection := db.Collection(req.CollectionName)
+	collection, err := collectionFor(client, req.DatabaseName, req.CollectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check total document count
 	totalCount, err := collection.CountDocuments(ctx, bson.M{})
@@ -372,31 +431,34 @@ func (s *CollectionService) Method3DetectSchema(req models.Method3SchemaRequest)
 }
 
 // Method3InsertData inserts data directly into external MongoDB (Method 3)
-func (s *CollectionService) Method3InsertData(req models.Method3DataInsertRequest) (*models.CreateDocumentResponse, error) {
+func (s *CollectionService) Method3InsertData(ctx context.Context, req models.Method3DataInsertRequest) (*models.CreateDocumentResponse, error) {
 	// Validate inputs
 	if !utils.IsValidDBName(req.DatabaseName) {
-		return nil, fmt.Errorf("invalid database name: %s", req.DatabaseName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, req.DatabaseName)
 	}
 
 	if !utils.IsValidCollectionName(req.CollectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", req.CollectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, req.CollectionName)
 	}
 
-	// Connect to external MongoDB using provided URI
-	client, err := mongodb.ConnectWithURI(req.MongoURI)
+	// Reuse a pooled client for the referenced connection
+	client, release, err := acquireClient(req.ConnectionRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to external MongoDB: %v", err)
+		return nil, err
 	}
-	defer client.Disconnect(context.Background())
+	defer release()
 
-	// Get the database and collection
-	db := client.Database(req.DatabaseName)
-	collection := db.Collection(req.CollectionName)
+	// Get the collection with the requested consistency settings
+	collection, err := collectionFor(client, req.DatabaseName, req.CollectionName, req.ConsistencyOptions)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
 	// Insert the document
+	stampVersion(req.Data)
 	result, err := collection.InsertOne(ctx, req.Data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert document: %v", err)
@@ -411,84 +473,209 @@ func (s *CollectionService) Method3InsertData(req models.Method3DataInsertReques
 	}, nil
 }
 
+// Method3BulkInsertData inserts many documents into external MongoDB (Method 3)
+func (s *CollectionService) Method3BulkInsertData(ctx context.Context, req models.Method3BulkInsertRequest) (*models.BulkInsertResponse, error) {
+	// Validate inputs
+	if !utils.IsValidDBName(req.DatabaseName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, req.DatabaseName)
+	}
+
+	if !utils.IsValidCollectionName(req.CollectionName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, req.CollectionName)
+	}
+
+	// Reuse a pooled client for the referenced connection
+	client, release, err := acquireClient(req.ConnectionRef)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Get the collection with the requested consistency settings
+	collection, err := collectionFor(client, req.DatabaseName, req.CollectionName, req.ConsistencyOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.LongTimeout)
+	defer cancel()
+
+	return bulkInsert(ctx, collection, req.DatabaseName, req.CollectionName, req.BulkInsertRequest)
+}
+
 // Method3GetData retrieves data from external MongoDB (Method 3)
-func (s *CollectionService) Method3GetData(req models.Method3DataRequest) (*models.CollectionEntriesResponse, error) {
+func (s *CollectionService) Method3GetData(ctx context.Context, req models.Method3DataRequest) (*models.CollectionEntriesResponse, error) {
 	// Validate inputs
 	if !utils.IsValidDBName(req.DatabaseName) {
-		return nil, fmt.Errorf("invalid database name: %s", req.DatabaseName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, req.DatabaseName)
 	}
 
 	if !utils.IsValidCollectionName(req.CollectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", req.CollectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, req.CollectionName)
+	}
+
+	if req.Skip < 0 {
+		return nil, fmt.Errorf("%w: skip must not be negative", ErrInvalidPagination)
+	}
+	sort, projection, err := parseSortAndProjection(req.Sort, req.Fields)
+	if err != nil {
+		return nil, err
 	}
 
-	// Connect to external MongoDB using provided URI
-	client, err := mongodb.ConnectWithURI(req.MongoURI)
+	// Reuse a pooled client for the referenced connection
+	client, release, err := acquireClient(req.ConnectionRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to external MongoDB: %v", err)
+		return nil, err
 	}
-	defer client.Disconnect(context.Background())
+	defer release()
 
-	// Get the database and collection
-	db := client.Database(req.DatabaseName)
-	collection := db.Collection(req.CollectionName)
+	// Get the collection with the requested consistency settings
+	collection, err := collectionFor(client, req.DatabaseName, req.CollectionName, req.ConsistencyOptions)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
 	defer cancel()
 
-	// Get all documents
-	cursor, err := collection.Find(ctx, bson.M{})
+	filterParam, err := filterString(req.Filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query collection: %v", err)
+		return nil, err
+	}
+	filter, err := buildFilter(ctx, collection, filterParam)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never load more than one capped page
+	limit := pageLimit(req.Limit)
+	pageReq := pageRequest{
+		Namespace:  req.DatabaseName + "." + req.CollectionName,
+		Filter:     filterParam,
+		Sort:       sort,
+		Projection: projection,
+		Limit:      limit,
+		Skip:       req.Skip,
+		Cursor:     req.Cursor,
+	}
+	if req.Explain {
+		plan, err := explainPage(ctx, collection, filter, pageReq)
+		if err != nil {
+			return nil, err
+		}
+		return explainEntriesResponse(req.DatabaseName, req.CollectionName, limit, req.Skip, plan), nil
 	}
-	defer cursor.Close(ctx)
 
-	var documents []bson.M
-	if err = cursor.All(ctx, &documents); err != nil {
-		return nil, fmt.Errorf("failed to decode documents: %v", err)
+	totalCount, estimated, err := countTotal(ctx, collection, filter, req.Count, req.Cursor != "")
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := findPage(ctx, collection, filter, pageReq)
+	if err != nil {
+		return nil, err
 	}
 
 	return &models.CollectionEntriesResponse{
 		Message:    "Entries retrieved successfully from external MongoDB",
 		Database:   req.DatabaseName,
 		Collection: req.CollectionName,
-		Data:       documents,
-		Count:      len(documents),
-		TotalCount: int64(len(documents)),
-		Limit:      50,
-		Skip:       0,
-		HasMore:    false,
+		Data:       page.Documents,
+		Count:      len(page.Documents),
+		TotalCount: totalCount,
+		Limit:      limit,
+		Skip:       page.Skip,
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
+		Estimated:  estimated,
 		Code:       0,
 	}, nil
 }
 
+// Method3StreamData streams Method 3 read results to emit instead of
+// collecting a page. Limit 0 streams every match; nothing is counted.
+func (s *CollectionService) Method3StreamData(ctx context.Context, req models.Method3DataRequest, emit DocumentEmitter) error {
+	if !utils.IsValidDBName(req.DatabaseName) {
+		return fmt.Errorf("%w: %s", ErrInvalidDatabaseName, req.DatabaseName)
+	}
+
+	if !utils.IsValidCollectionName(req.CollectionName) {
+		return fmt.Errorf("%w: %s", ErrInvalidCollectionName, req.CollectionName)
+	}
+
+	if req.Limit < 0 || req.Skip < 0 {
+		return fmt.Errorf("%w: limit and skip must not be negative", ErrInvalidPagination)
+	}
+	sort, projection, err := parseSortAndProjection(req.Sort, req.Fields)
+	if err != nil {
+		return err
+	}
+
+	client, release, err := acquireClient(req.ConnectionRef)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, req.DatabaseName, req.CollectionName, req.ConsistencyOptions)
+	if err != nil {
+		return err
+	}
+
+	// Only setup is bounded; the stream runs until it ends or the client
+	// disconnects, with streamPage enforcing an idle timeout between batches
+	setupCtx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
+	defer cancel()
+
+	filterParam, err := filterString(req.Filter)
+	if err != nil {
+		return err
+	}
+	filter, err := buildFilter(setupCtx, collection, filterParam)
+	if err != nil {
+		return err
+	}
+
+	return streamPage(ctx, collection, filter, pageRequest{
+		Namespace:  req.DatabaseName + "." + req.CollectionName,
+		Filter:     filterParam,
+		Sort:       sort,
+		Projection: projection,
+		Limit:      req.Limit,
+		Skip:       req.Skip,
+		Cursor:     req.Cursor,
+	}, emit)
+}
+
 // Method3DeleteData deletes data from external MongoDB (Method 3)
-func (s *CollectionService) Method3DeleteData(req models.Method3DataRequest) (*models.DeleteDocumentResponse, error) {
+func (s *CollectionService) Method3DeleteData(ctx context.Context, req models.Method3DataRequest) (*models.DeleteDocumentResponse, error) {
 	// Validate inputs
 	if !utils.IsValidDBName(req.DatabaseName) {
-		return nil, fmt.Errorf("invalid database name: %s", req.DatabaseName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, req.DatabaseName)
 	}
 
 	if !utils.IsValidCollectionName(req.CollectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", req.CollectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, req.CollectionName)
 	}
 
 	if req.DocumentID == "" {
 		return nil, fmt.Errorf("document ID is required for deletion")
 	}
 
-	// Connect to external MongoDB using provided URI
-	client, err := mongodb.ConnectWithURI(req.MongoURI)
+	// Reuse a pooled client for the referenced connection
+	client, release, err := acquireClient(req.ConnectionRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to external MongoDB: %v", err)
+		return nil, err
 	}
-	defer client.Disconnect(context.Background())
+	defer release()
 
-	// Get the database and collection
-	db := client.Database(req.DatabaseName)
-	collection := db.Collection(req.CollectionName)
+	// Get the collection with the requested consistency settings
+	collection, err := collectionFor(client, req.DatabaseName, req.CollectionName, req.ConsistencyOptions)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
 	// Convert string ID to ObjectID
@@ -504,7 +691,7 @@ func (s *CollectionService) Method3DeleteData(req models.Method3DataRequest) (*m
 	}
 
 	if result.DeletedCount == 0 {
-		return nil, fmt.Errorf("document not found")
+		return nil, ErrDocumentNotFound
 	}
 
 	return &models.DeleteDocumentResponse{
@@ -518,7 +705,7 @@ func (s *CollectionService) Method3DeleteData(req models.Method3DataRequest) (*m
 }
 
 // Method3AddSchemaFields adds new fields to a collection's schema using external MongoDB URI
-func (s *CollectionService) Method3AddSchemaFields(req models.Method3SchemaModificationRequest) (*models.Method3SchemaModificationResponse, error) {
+func (s *CollectionService) Method3AddSchemaFields(ctx context.Context, req models.Method3SchemaModificationRequest) (*models.Method3SchemaModificationResponse, error) {
 	if req.DatabaseName == "" {
 		return nil, fmt.Errorf("database name is required")
 	}
@@ -530,22 +717,21 @@ func (s *CollectionService) Method3AddSchemaFields(req models.Method3SchemaModif
 	}
 
 	// ⚠️ SECURITY FIX: MongoDB URI should be provided by main server, not hardcoded
-	// This service should receive connection from main server that manages URIs securely
-	if req.MongoURI == "" {
-		return nil, fmt.Errorf("MongoDB URI must be provided by main server")
+	// This service should receive a connection handle (or URI) from the main server
+	client, release, err := acquireClient(req.ConnectionRef)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
-	client, err := mongodb.ConnectWithURI(req.MongoURI)
+	// Get the collection with the requested consistency settings
+	collection, err := collectionFor(client, req.DatabaseName, req.CollectionName, req.ConsistencyOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to external MongoDB: %v", err)
+		return nil, err
 	}
-	defer client.Disconnect(context.TODO())
-
-	// Get the collection
-	collection := client.Database(req.DatabaseName).Collection(req.CollectionName)
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.MediumTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
 	defer cancel()
 
 	// For MongoDB, we don't modify the schema directly since it's schema-less
@@ -566,7 +752,7 @@ func (s *CollectionService) Method3AddSchemaFields(req models.Method3SchemaModif
 }
 
 // Method3RemoveSchemaField removes a field from a collection's documents using external MongoDB URI
-func (s *CollectionService) Method3RemoveSchemaField(req models.Method3SchemaFieldRemovalRequest) (*models.Method3SchemaModificationResponse, error) {
+func (s *CollectionService) Method3RemoveSchemaField(ctx context.Context, req models.Method3SchemaFieldRemovalRequest) (*models.Method3SchemaModificationResponse, error) {
 	if req.DatabaseName == "" {
 		return nil, fmt.Errorf("database name is required")
 	}
@@ -581,22 +767,21 @@ func (s *CollectionService) Method3RemoveSchemaField(req models.Method3SchemaFie
 	}
 
 	// ⚠️ SECURITY FIX: MongoDB URI should be provided by main server, not hardcoded
-	// This service should receive connection from main server that manages URIs securely
-	if req.MongoURI == "" {
-		return nil, fmt.Errorf("MongoDB URI must be provided by main server")
+	// This service should receive a connection handle (or URI) from the main server
+	client, release, err := acquireClient(req.ConnectionRef)
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
-	client, err := mongodb.ConnectWithURI(req.MongoURI)
+	// Get the collection with the requested consistency settings
+	collection, err := collectionFor(client, req.DatabaseName, req.CollectionName, req.ConsistencyOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to external MongoDB: %v", err)
+		return nil, err
 	}
-	defer client.Disconnect(context.TODO())
-
-	// Get the collection
-	collection := client.Database(req.DatabaseName).Collection(req.CollectionName)
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.LongTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.LongTimeout)
 	defer cancel()
 
 	// Remove the field from all documents in the collection
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/configs"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/mongodb"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Connection handle lifetime bounds
+const (
+	defaultConnectionTTL = 30 * time.Minute
+	maxConnectionTTL     = 24 * time.Hour
+)
+
+// ErrConnectionRequired is returned when a request names no connection at all
+var ErrConnectionRequired = errors.New("one of connection_id, mongo_uri_enc or mongo_uri is required")
+
+type ConnectionService struct {
+	databaseService *DatabaseService
+}
+
+func NewConnectionService() *ConnectionService {
+	return &ConnectionService{
+		databaseService: NewDatabaseService(),
+	}
+}
+
+// CreateConnection validates a MongoDB URI and registers it under an opaque handle
+func (s *ConnectionService) CreateConnection(ctx context.Context, req models.CreateConnectionRequest) (*models.ConnectionResponse, error) {
+	mongoURI, _, err := resolveConnection(models.ConnectionRef{MongoURIEnc: req.MongoURIEnc, MongoURI: req.MongoURI})
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate PEM material up front so bad bundles fail before any dial
+	tlsOpts := toMongoTLSOptions(req.TLS)
+	if _, err := mongodb.BuildTLSConfig(tlsOpts); err != nil {
+		return nil, err
+	}
+
+	if err := s.databaseService.verifyMongoURIAccess(ctx, mongoURI, tlsOpts, req.DatabaseName); err != nil {
+		return nil, err
+	}
+
+	ttl := connectionTTL(req.TTLSeconds)
+	conn, err := mongodb.RegisterConnection(mongoURI, req.DatabaseName, tlsOpts, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register connection: %v", err)
+	}
+
+	return &models.ConnectionResponse{
+		Message:      "Connection registered successfully",
+		ConnectionID: conn.ID,
+		Database:     conn.Database,
+		ExpiresAt:    conn.ExpiresAt,
+		TTLSeconds:   int(ttl.Seconds()),
+		Code:         0,
+	}, nil
+}
+
+// DeleteConnection removes a connection handle before it expires
+func (s *ConnectionService) DeleteConnection(connectionID string) error {
+	if !mongodb.RemoveConnection(connectionID) {
+		return mongodb.ErrConnectionNotFound
+	}
+	return nil
+}
+
+// connectionTTL clamps the requested TTL to the allowed range
+func connectionTTL(ttlSeconds int) time.Duration {
+	ttl := defaultConnectionTTL
+	if configs.Env != nil && configs.Env.ConnectionHandleTTL > 0 {
+		ttl = configs.Env.ConnectionHandleTTL
+	}
+	if ttlSeconds > 0 {
+		ttl = time.Duration(ttlSeconds) * time.Second
+	}
+	if ttl > maxConnectionTTL {
+		ttl = maxConnectionTTL
+	}
+	return ttl
+}
+
+// toMongoTLSOptions converts request TLS material to the driver-side type
+func toMongoTLSOptions(opts *models.TLSOptions) *mongodb.TLSOptions {
+	if opts == nil {
+		return nil
+	}
+	return &mongodb.TLSOptions{
+		CAPEM:   opts.CAPEM,
+		CertPEM: opts.CertPEM,
+		KeyPEM:  opts.KeyPEM,
+	}
+}
+
+// resolveConnection returns the plaintext URI and TLS material a connection
+// reference points to. Only registered handles carry TLS material.
+func resolveConnection(ref models.ConnectionRef) (string, *mongodb.TLSOptions, error) {
+	if ref.ConnectionID != "" {
+		conn, err := mongodb.ResolveConnection(ref.ConnectionID)
+		if err != nil {
+			return "", nil, err
+		}
+		return conn.URI, conn.TLS, nil
+	}
+
+	// Encrypted URIs are only ever decrypted in memory
+	if ref.MongoURIEnc != "" {
+		mongoURI, err := utils.DecryptString(ref.MongoURIEnc)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to decrypt mongo_uri_enc: %w", err)
+		}
+		return mongoURI, nil, nil
+	}
+
+	if ref.MongoURI != "" {
+		return ref.MongoURI, nil, nil
+	}
+
+	return "", nil, ErrConnectionRequired
+}
+
+// acquireClient resolves a connection reference to a pooled client. The
+// returned release func must be called once the caller is done.
+func acquireClient(ref models.ConnectionRef) (*mongo.Client, func(), error) {
+	mongoURI, tlsOpts, err := resolveConnection(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, release, err := mongodb.AcquireClient(mongoURI, tlsOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to external MongoDB: %w", err)
+	}
+
+	return client, release, nil
+}
+
+// collectionFor returns a collection handle honoring the request's read
+// preference, read concern and write concern
+func collectionFor(client *mongo.Client, dbName, collectionName string, consistency models.ConsistencyOptions) (*mongo.Collection, error) {
+	collOpts, err := mongodb.CollectionOptions(consistency.ReadPreference, consistency.ReadConcern, consistency.WriteConcern)
+	if err != nil {
+		return nil, err
+	}
+	return client.Database(dbName).Collection(collectionName, collOpts), nil
+}
@@ -18,44 +18,74 @@ func NewDatabaseService() *DatabaseService {
 }
 
 // AllocateDatabase creates a new database with user credentials or tests existing MongoDB URI
-func (s *DatabaseService) AllocateDatabase(req models.DatabaseAllocationRequest) (*models.DatabaseAllocationResponse, error) {
+func (s *DatabaseService) AllocateDatabase(ctx context.Context, req models.DatabaseAllocationRequest) (*models.DatabaseAllocationResponse, error) {
 	// Method 3: Direct MongoDB URI connection (no user creation needed)
-	if req.MongoURI != "" {
-		return s.connectWithMongoURI(req)
+	if req.MongoURIEnc != "" || req.MongoURI != "" {
+		return s.connectWithMongoURI(ctx, req)
 	}
 
 	// Original method: Create users (for local MongoDB)
-	return s.allocateWithUserCreation(req)
+	return s.allocateWithUserCreation(ctx, req)
 }
 
 // connectWithMongoURI handles Method 3: Direct connection using MongoDB URI
-func (s *DatabaseService) connectWithMongoURI(req models.DatabaseAllocationRequest) (*models.DatabaseAllocationResponse, error) {
+func (s *DatabaseService) connectWithMongoURI(ctx context.Context, req models.DatabaseAllocationRequest) (*models.DatabaseAllocationResponse, error) {
+	mongoURI, _, err := resolveConnection(models.ConnectionRef{MongoURIEnc: req.MongoURIEnc, MongoURI: req.MongoURI})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyMongoURIAccess(ctx, mongoURI, nil, req.DBName); err != nil {
+		return nil, err
+	}
+
+	// Never echo a decrypted URI back; encrypted callers get the ciphertext
+	responseURI := req.MongoURI
+	if req.MongoURIEnc != "" {
+		responseURI = req.MongoURIEnc
+	}
+
+	// Create response
+	response := &models.DatabaseAllocationResponse{
+		Message:  fmt.Sprintf("Successfully connected to existing database '%s' using provided MongoDB URI", req.DBName),
+		Code:     200,
+		URI:      responseURI,
+		Database: req.DBName,
+		Username: "external", // Using external credentials from URI
+	}
+
+	return response, nil
+}
+
+// verifyMongoURIAccess checks that the URI connects and that the database
+// exists and accepts reads and writes
+func (s *DatabaseService) verifyMongoURIAccess(ctx context.Context, mongoURI string, tlsOpts *mongodb.TLSOptions, dbName string) error {
 	// Validate database name
-	if !utils.IsValidDBName(req.DBName) {
-		return nil, fmt.Errorf("invalid database name: %s", req.DBName)
+	if !utils.IsValidDBName(dbName) {
+		return fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
-	// Connect to MongoDB using the provided URI
-	mongoClient, err := mongodb.ConnectWithURI(req.MongoURI)
+	// Connect to MongoDB using the provided URI (pooled for later Method 3 calls)
+	mongoClient, release, err := mongodb.AcquireClient(mongoURI, tlsOpts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
-	defer mongoClient.Disconnect(context.Background())
+	defer release()
 
 	// Test connection with the provided MongoDB URI
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.MediumTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
 	defer cancel()
 
 	// List existing databases to validate if the requested database exists
 	databases, err := mongoClient.ListDatabaseNames(ctx, bson.M{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list databases: %v", err)
+		return fmt.Errorf("failed to list databases: %v", err)
 	}
 
 	// Check if the requested database exists
 	dbExists := false
-	for _, dbName := range databases {
-		if dbName == req.DBName {
+	for _, existing := range databases {
+		if existing == dbName {
 			dbExists = true
 			break
 		}
@@ -63,45 +93,36 @@ func (s *DatabaseService) connectWithMongoURI(req models.DatabaseAllocationReque
 
 	// If database doesn't exist, return an error with available databases
 	if !dbExists {
-		return nil, fmt.Errorf("database '%s' does not exist. Available databases: %v", req.DBName, databases)
+		return fmt.Errorf("database '%s' does not exist. Available databases: %v", dbName, databases)
 	}
 
 	// Test database access
-	db := mongoClient.Database(req.DBName)
+	db := mongoClient.Database(dbName)
 
 	// Try to ping the database
-	err = db.RunCommand(ctx, bson.D{{"ping", 1}}).Err()
+	err = db.RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Err()
 	if err != nil {
-		return nil, fmt.Errorf("failed to ping database: %v", err)
+		return fmt.Errorf("failed to ping database: %v", err)
 	}
 
 	// Test write access by creating a test collection
 	testCollection := db.Collection("_connection_test")
 	_, err = testCollection.InsertOne(ctx, bson.M{"test": "connection", "timestamp": time.Now()})
 	if err != nil {
-		return nil, fmt.Errorf("failed to test write access: %v", err)
+		return fmt.Errorf("failed to test write access: %v", err)
 	}
 
 	// Clean up test document
 	testCollection.DeleteMany(ctx, bson.M{"test": "connection"})
 
-	// Create response
-	response := &models.DatabaseAllocationResponse{
-		Message:  fmt.Sprintf("Successfully connected to existing database '%s' using provided MongoDB URI", req.DBName),
-		Code:     200,
-		URI:      req.MongoURI,
-		Database: req.DBName,
-		Username: "external", // Using external credentials from URI
-	}
-
-	return response, nil
+	return nil
 }
 
 // allocateWithUserCreation handles the original method with user creation
-func (s *DatabaseService) allocateWithUserCreation(req models.DatabaseAllocationRequest) (*models.DatabaseAllocationResponse, error) {
+func (s *DatabaseService) allocateWithUserCreation(ctx context.Context, req models.DatabaseAllocationRequest) (*models.DatabaseAllocationResponse, error) {
 	// Validate input
 	if !utils.IsValidDBName(req.DBName) {
-		return nil, fmt.Errorf("invalid database name: %s", req.DBName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, req.DBName)
 	}
 
 	if !utils.IsValidUsername(req.UserName) {
@@ -112,49 +133,30 @@ func (s *DatabaseService) allocateWithUserCreation(req models.DatabaseAllocation
 		return nil, fmt.Errorf("invalid password: must be 6-128 characters")
 	}
 
-	// Get MongoDB client
-	client := mongodb.GetClient()
-	db := client.Database(req.DBName)
-
-	// Create user with readWrite permissions
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
-	defer cancel()
-
-	err := db.RunCommand(ctx, bson.D{
-		{Key: "createUser", Value: req.UserName},
-		{Key: "pwd", Value: req.Password},
-		{Key: "roles", Value: bson.A{
-			bson.D{
-				{Key: "role", Value: "readWrite"},
-				{Key: "db", Value: req.DBName},
-			},
-		}},
-	}).Err()
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
-	}
-
 	// ⚠️ SECURITY: This function is deprecated for security reasons
 	// Database user creation should be handled by main server, not microservices
 	// MongoDB hostname and credentials should not be stored in microservice environment
-	return nil, fmt.Errorf("database user creation deprecated: should be handled by main server for security")
+	return nil, fmt.Errorf("%w: database user creation should be handled by main server, provide mongo_uri_enc or mongo_uri instead", ErrNotSupported)
 }
 
 // TestDatabaseConnection tests if a database is accessible
-func (s *DatabaseService) TestDatabaseConnection(dbName string) error {
+func (s *DatabaseService) TestDatabaseConnection(ctx context.Context, conn models.ConnectionRef, dbName string) error {
 	if !utils.IsValidDBName(dbName) {
-		return fmt.Errorf("invalid database name: %s", dbName)
+		return fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
-	client := mongodb.GetClient()
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return err
+	}
+	defer release()
 	db := client.Database(dbName)
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
 	// Try to ping the database
-	err := db.Client().Ping(ctx, nil)
+	err = db.RunCommand(ctx, bson.D{{Key: "ping", Value: 1}}).Err()
 	if err != nil {
 		return fmt.Errorf("failed to connect to database %s: %v", dbName, err)
 	}
@@ -163,15 +165,19 @@ func (s *DatabaseService) TestDatabaseConnection(dbName string) error {
 }
 
 // GetDatabaseInfo retrieves basic information about a database
-func (s *DatabaseService) GetDatabaseInfo(dbName string) (map[string]interface{}, error) {
+func (s *DatabaseService) GetDatabaseInfo(ctx context.Context, conn models.ConnectionRef, dbName string) (map[string]interface{}, error) {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
-	client := mongodb.GetClient()
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	db := client.Database(dbName)
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
 	// Get collections count
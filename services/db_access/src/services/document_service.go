@@ -2,12 +2,14 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
-	"github.com/abhidhanve/universal-dashboard/services/db_access/src/mongodb"
 	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -18,28 +20,36 @@ func NewDocumentService() *DocumentService {
 }
 
 // CreateDocument creates a new document in a specified collection
-func (s *DocumentService) CreateDocument(dbName, collectionName string, req models.CreateDocumentRequest) (*models.CreateDocumentResponse, error) {
+func (s *DocumentService) CreateDocument(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName string, req models.CreateDocumentRequest) (*models.CreateDocumentResponse, error) {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
 	if !utils.IsValidCollectionName(collectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", collectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
 	}
 
 	if !utils.ValidateDocumentData(req.Data) {
 		return nil, fmt.Errorf("invalid document data")
 	}
 
-	client := mongodb.GetClient()
-	db := client.Database(dbName)
-	collection := db.Collection(collectionName)
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
 	// Sanitize document data
 	sanitizedData := utils.SanitizeDocumentData(req.Data)
+	stampVersion(sanitizedData)
 
 	result, err := collection.InsertOne(ctx, sanitizedData)
 	if err != nil {
@@ -57,154 +67,407 @@ func (s *DocumentService) CreateDocument(dbName, collectionName string, req mode
 	return response, nil
 }
 
+// InsertDocuments inserts many documents in batches, reporting the outcome of
+// each one
+func (s *DocumentService) InsertDocuments(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName string, req models.BulkInsertRequest) (*models.BulkInsertResponse, error) {
+	if !utils.IsValidDBName(dbName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
+	}
+
+	if !utils.IsValidCollectionName(collectionName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
+	}
+
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.LongTimeout)
+	defer cancel()
+
+	return bulkInsert(ctx, collection, dbName, collectionName, req)
+}
+
 // GetCollectionEntries retrieves all entries from a specific collection with pagination
-func (s *DocumentService) GetCollectionEntries(dbName, collectionName string, limit, skip int) (*models.CollectionEntriesResponse, error) {
+func (s *DocumentService) GetCollectionEntries(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName string, query models.QueryParams) (*models.CollectionEntriesResponse, error) {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
 	if !utils.IsValidCollectionName(collectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", collectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
 	}
 
 	// Validate pagination parameters
-	if limit < 1 || limit > 1000 {
-		limit = 50
-	}
+	limit, skip := pageLimit(query.Limit), query.Skip
 	if skip < 0 {
 		skip = 0
 	}
 
-	client := mongodb.GetClient()
-	db := client.Database(dbName)
-	collection := db.Collection(collectionName)
+	sort, projection, err := parseSortAndProjection(query.Sort, query.Fields)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.MediumTimeout)
-	defer cancel()
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	// Get total count
-	totalCount, err := collection.CountDocuments(ctx, bson.M{})
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count documents: %v", err)
+		return nil, err
 	}
 
-	// Find documents with pagination
-	findOptions := options.Find().SetLimit(int64(limit)).SetSkip(int64(skip))
-	cursor, err := collection.Find(ctx, bson.M{}, findOptions)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
+	defer cancel()
+
+	filter, err := buildFilter(ctx, collection, query.Filter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query collection: %v", err)
+		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	var documents []bson.M
-	if err = cursor.All(ctx, &documents); err != nil {
-		return nil, fmt.Errorf("failed to decode documents: %v", err)
+	req := pageRequest{
+		Namespace:  dbName + "." + collectionName,
+		Filter:     query.Filter,
+		Sort:       sort,
+		Projection: projection,
+		Limit:      limit,
+		Skip:       skip,
+		Cursor:     query.Cursor,
+	}
+	if query.Explain {
+		plan, err := explainPage(ctx, collection, filter, req)
+		if err != nil {
+			return nil, err
+		}
+		return explainEntriesResponse(dbName, collectionName, limit, skip, plan), nil
 	}
 
-	// Calculate if there are more documents
-	hasMore := utils.CalculateHasMore(skip, len(documents), totalCount)
+	// Cursor pages skip counting by default; clients already have the total
+	// from the first page and deep counts are what cursors avoid
+	totalCount, estimated, err := countTotal(ctx, collection, filter, query.Count, query.Cursor != "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Find documents with skip or keyset pagination
+	page, err := findPage(ctx, collection, filter, req)
+	if err != nil {
+		return nil, err
+	}
 
 	response := &models.CollectionEntriesResponse{
 		Message:    "Entries retrieved successfully",
 		Database:   dbName,
 		Collection: collectionName,
-		Data:       documents,
-		Count:      len(documents),
+		Data:       page.Documents,
+		Count:      len(page.Documents),
 		TotalCount: totalCount,
 		Limit:      limit,
-		Skip:       skip,
-		HasMore:    hasMore,
+		Skip:       page.Skip,
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
+		Estimated:  estimated,
 		Code:       0,
 	}
 
 	return response, nil
 }
 
-// UpdateDocument updates a specific document in a collection
-func (s *DocumentService) UpdateDocument(dbName, collectionName, entryID string, req models.UpdateDocumentRequest) (*models.UpdateDocumentResponse, error) {
+// StreamCollectionEntries streams the documents of a listing to emit instead
+// of collecting a page. Limit 0 streams every match; nothing is counted.
+func (s *DocumentService) StreamCollectionEntries(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName string, query models.QueryParams, emit DocumentEmitter) error {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
 	if !utils.IsValidCollectionName(collectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", collectionName)
+		return fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
 	}
 
-	if entryID == "" {
-		return nil, fmt.Errorf("document ID cannot be empty")
+	if query.Limit < 0 || query.Skip < 0 {
+		return fmt.Errorf("%w: limit and skip must not be negative", ErrInvalidPagination)
 	}
 
-	if !utils.ValidateDocumentData(req.Data) {
-		return nil, fmt.Errorf("invalid document data")
+	sort, projection, err := parseSortAndProjection(query.Sort, query.Fields)
+	if err != nil {
+		return err
 	}
 
-	client := mongodb.GetClient()
-	db := client.Database(dbName)
-	collection := db.Collection(collectionName)
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	// Only setup is bounded; the stream runs until it ends or the client
+	// disconnects, with streamPage enforcing an idle timeout between batches
+	setupCtx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
-	// Create filter for document ID
-	filter := utils.CreateMongoFilter(entryID)
+	filter, err := buildFilter(setupCtx, collection, query.Filter)
+	if err != nil {
+		return err
+	}
+
+	return streamPage(ctx, collection, filter, pageRequest{
+		Namespace:  dbName + "." + collectionName,
+		Filter:     query.Filter,
+		Sort:       sort,
+		Projection: projection,
+		Limit:      query.Limit,
+		Skip:       query.Skip,
+		Cursor:     query.Cursor,
+	}, emit)
+}
+
+// documentUpdateOperators are the update operators PATCH accepts
+var documentUpdateOperators = map[string]bool{
+	"$set":         true,
+	"$unset":       true,
+	"$inc":         true,
+	"$push":        true,
+	"$pull":        true,
+	"$addToSet":    true,
+	"$currentDate": true,
+}
+
+// UpdateDocument sets the given fields of a document, or replaces it entirely
+// with opts.Replace
+func (s *DocumentService) UpdateDocument(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, entryID string, req models.UpdateDocumentRequest, opts models.UpdateOptions) (*models.UpdateDocumentResponse, error) {
+	if !utils.ValidateDocumentData(req.Data) {
+		return nil, fmt.Errorf("%w: data must not be empty", utils.ErrInvalidUpdate)
+	}
 
 	// Sanitize update data
 	sanitizedData := utils.SanitizeDocumentData(req.Data)
-	update := bson.M{"$set": sanitizedData}
+	if !opts.Replace {
+		return s.updateOne(ctx, conn, consistency, dbName, collectionName, entryID, bson.D{{Key: "$set", Value: sanitizedData}}, opts)
+	}
+
+	// The path decides the document ID; a replacement cannot change it
+	delete(sanitizedData, "_id")
+	for field := range sanitizedData {
+		if strings.HasPrefix(field, "$") {
+			return nil, fmt.Errorf("%w: a replacement document cannot contain operators such as %s", utils.ErrInvalidUpdate, field)
+		}
+	}
+	return s.updateOne(ctx, conn, consistency, dbName, collectionName, entryID, sanitizedData, opts)
+}
+
+// PatchDocument applies an update document built from the allowlisted
+// operators in documentUpdateOperators
+func (s *DocumentService) PatchDocument(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, entryID string, req models.PatchDocumentRequest, opts models.UpdateOptions) (*models.UpdateDocumentResponse, error) {
+	update, err := utils.ParseUpdate(req.Update, documentUpdateOperators)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Replace = false
+	return s.updateOne(ctx, conn, consistency, dbName, collectionName, entryID, update, opts)
+}
 
-	result, err := collection.UpdateOne(ctx, filter, update)
+// updateOne applies an update document, or a replacement with opts.Replace,
+// to the document with entryID. With opts.IfMatch the write only applies to
+// the version of the document the client last read.
+func (s *DocumentService) updateOne(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, entryID string, update interface{}, opts models.UpdateOptions) (*models.UpdateDocumentResponse, error) {
+	if !utils.IsValidDBName(dbName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
+	}
+
+	if !utils.IsValidCollectionName(collectionName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
+	}
+
+	if entryID == "" {
+		return nil, fmt.Errorf("document ID cannot be empty")
+	}
+
+	client, release, err := acquireClient(conn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update document: %v", err)
+		return nil, err
 	}
+	defer release()
 
-	if result.MatchedCount == 0 {
-		return nil, fmt.Errorf("document not found")
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
+	defer cancel()
+
+	// Create filter for document ID
+	filter := utils.CreateMongoFilter(entryID)
+
 	response := &models.UpdateDocumentResponse{
-		Message:       "Document updated successfully",
-		Database:      dbName,
-		Collection:    collectionName,
-		DocumentID:    entryID,
-		MatchedCount:  result.MatchedCount,
-		ModifiedCount: result.ModifiedCount,
-		Code:          0,
+		Message:    "Document updated successfully",
+		Database:   dbName,
+		Collection: collectionName,
+		DocumentID: entryID,
+		Code:       0,
+	}
+	if opts.Replace {
+		response.Message = "Document replaced successfully"
 	}
 
+	if update, opts.Replace, err = versionedUpdate(update, opts.Replace); err != nil {
+		return nil, err
+	}
+
+	if opts.IfMatch != "" {
+		if filter, err = guardFilter(ctx, collection, filter, opts.IfMatch); err != nil {
+			return nil, err
+		}
+		// A precondition on the current version rules out creating one
+		opts.Upsert = false
+	}
+
+	if opts.ReturnDocument {
+		err = findAndUpdateOne(ctx, collection, filter, update, opts, response)
+	} else {
+		err = updateOneResult(ctx, collection, filter, update, opts, response)
+	}
+	if errors.Is(err, ErrDocumentNotFound) && opts.IfMatch != "" {
+		return nil, fmt.Errorf("%w: document changed while it was being updated", ErrPreconditionFailed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if response.UpsertedID != nil {
+		response.Message = "Document created by upsert"
+	}
+
+	// The write already succeeded, so a failed read only leaves out the ETag
+	if version, err := readVersion(ctx, collection, utils.CreateMongoFilter(entryID)); err == nil {
+		response.ETag = version.ETag
+	}
 	return response, nil
 }
 
-// DeleteDocument deletes a specific document from a collection
-func (s *DocumentService) DeleteDocument(dbName, collectionName, entryID string) (*models.DeleteDocumentResponse, error) {
+// updateOneResult runs UpdateOne or ReplaceOne and records the counts
+func updateOneResult(ctx context.Context, collection *mongo.Collection, filter bson.M, update interface{}, opts models.UpdateOptions, response *models.UpdateDocumentResponse) error {
+	var result *mongo.UpdateResult
+	var err error
+	if opts.Replace {
+		result, err = collection.ReplaceOne(ctx, filter, update, options.Replace().SetUpsert(opts.Upsert))
+	} else {
+		result, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(opts.Upsert))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update document: %w", err)
+	}
+
+	if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+		return ErrDocumentNotFound
+	}
+
+	response.MatchedCount = result.MatchedCount
+	response.ModifiedCount = result.ModifiedCount
+	response.UpsertedID = result.UpsertedID
+	return nil
+}
+
+// findAndUpdateOne runs FindOneAndUpdate or FindOneAndReplace and returns the
+// document as it is after the write. These commands do not say whether an
+// upsert inserted, so the insert is only attempted once a plain update found
+// nothing.
+func findAndUpdateOne(ctx context.Context, collection *mongo.Collection, filter bson.M, update interface{}, opts models.UpdateOptions, response *models.UpdateDocumentResponse) error {
+	find := func(upsert bool) (bson.M, error) {
+		var result *mongo.SingleResult
+		if opts.Replace {
+			result = collection.FindOneAndReplace(ctx, filter, update, options.FindOneAndReplace().SetReturnDocument(options.After).SetUpsert(upsert))
+		} else {
+			result = collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(upsert))
+		}
+		var document bson.M
+		err := result.Decode(&document)
+		return document, err
+	}
+
+	document, err := find(false)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments) && opts.Upsert:
+		if document, err = find(true); err != nil {
+			return fmt.Errorf("failed to upsert document: %w", err)
+		}
+		response.UpsertedID = document["_id"]
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrDocumentNotFound
+	case err != nil:
+		return fmt.Errorf("failed to update document: %w", err)
+	default:
+		response.MatchedCount = 1
+		response.ModifiedCount = 1
+	}
+
+	response.Document = document
+	return nil
+}
+
+// DeleteDocument deletes a specific document from a collection. A non-empty
+// ifMatch only deletes the version of the document the client last read.
+func (s *DocumentService) DeleteDocument(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, entryID, ifMatch string) (*models.DeleteDocumentResponse, error) {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
 	if !utils.IsValidCollectionName(collectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", collectionName)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
 	}
 
 	if entryID == "" {
 		return nil, fmt.Errorf("document ID cannot be empty")
 	}
 
-	client := mongodb.GetClient()
-	db := client.Database(dbName)
-	collection := db.Collection(collectionName)
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
 	// Create filter for document ID
 	filter := utils.CreateMongoFilter(entryID)
 
+	if ifMatch != "" {
+		if filter, err = guardFilter(ctx, collection, filter, ifMatch); err != nil {
+			return nil, err
+		}
+	}
+
 	result, err := collection.DeleteOne(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete document: %v", err)
 	}
 
+	if result.DeletedCount == 0 && ifMatch != "" {
+		return nil, fmt.Errorf("%w: document changed while it was being deleted", ErrPreconditionFailed)
+	}
 	if result.DeletedCount == 0 {
-		return nil, fmt.Errorf("document not found")
+		return nil, ErrDocumentNotFound
 	}
 
 	response := &models.DeleteDocumentResponse{
@@ -219,35 +482,55 @@ func (s *DocumentService) DeleteDocument(dbName, collectionName, entryID string)
 	return response, nil
 }
 
-// GetDocumentByID retrieves a specific document by its ID
-func (s *DocumentService) GetDocumentByID(dbName, collectionName, entryID string) (bson.M, error) {
+// GetDocumentByID retrieves a specific document by its ID along with its ETag
+func (s *DocumentService) GetDocumentByID(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, entryID string) (bson.M, string, error) {
 	if !utils.IsValidDBName(dbName) {
-		return nil, fmt.Errorf("invalid database name: %s", dbName)
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
 	}
 
 	if !utils.IsValidCollectionName(collectionName) {
-		return nil, fmt.Errorf("invalid collection name: %s", collectionName)
+		return nil, "", fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
 	}
 
 	if entryID == "" {
-		return nil, fmt.Errorf("document ID cannot be empty")
+		return nil, "", fmt.Errorf("document ID cannot be empty")
+	}
+
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, "", err
 	}
+	defer release()
 
-	client := mongodb.GetClient()
-	db := client.Database(dbName)
-	collection := db.Collection(collectionName)
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, "", err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), models.DefaultContextConfig.ShortTimeout)
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.ShortTimeout)
 	defer cancel()
 
 	// Create filter for document ID
 	filter := utils.CreateMongoFilter(entryID)
 
+	// The raw bytes are kept so the ETag hashes the document as stored
+	raw, err := collection.FindOne(ctx, filter).DecodeBytes()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, "", ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get document: %v", err)
+	}
+
 	var document bson.M
-	err := collection.FindOne(ctx, filter).Decode(&document)
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return nil, "", fmt.Errorf("failed to decode document: %v", err)
+	}
+
+	version, err := versionOf(raw)
 	if err != nil {
-		return nil, fmt.Errorf("document not found: %v", err)
+		return nil, "", err
 	}
 
-	return document, nil
+	return document, version.ETag, nil
 }
@@ -0,0 +1,16 @@
+package services
+
+import "errors"
+
+// Errors shared by the collection, document and database services. Messages
+// match the strings these services returned before the sentinels existed.
+var (
+	ErrInvalidDatabaseName   = errors.New("invalid database name")
+	ErrInvalidCollectionName = errors.New("invalid collection name")
+	ErrDocumentNotFound      = errors.New("document not found")
+	ErrNotSupported          = errors.New("operation not supported")
+	ErrInvalidQuery          = errors.New("invalid query")
+	ErrInvalidConfirmation   = errors.New("invalid confirmation")
+	ErrWriteLimitExceeded    = errors.New("write limit exceeded")
+	ErrPreconditionFailed    = errors.New("precondition failed")
+)
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/configs"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// versionCounterField is maintained on every write when DOCUMENT_AUTO_VERSION
+// is enabled
+const versionCounterField = "_v"
+
+// documentVersion identifies one version of a document: the ETag clients see
+// and the filter that only matches that version
+type documentVersion struct {
+	ETag  string
+	Guard bson.M
+}
+
+// versionField returns the field ETags are taken from, or "" to hash the
+// whole document
+func versionField() string {
+	if configs.Env == nil {
+		return ""
+	}
+	if configs.Env.DocumentVersionField != "" {
+		return configs.Env.DocumentVersionField
+	}
+	if configs.Env.DocumentAutoVersion {
+		return versionCounterField
+	}
+	return ""
+}
+
+// autoVersion reports whether writes maintain the _v counter
+func autoVersion() bool {
+	return configs.Env != nil && configs.Env.DocumentAutoVersion
+}
+
+// versionOf derives the version of a stored document. With a version field
+// present the ETag is its value; otherwise it is a hash of the document and
+// the guard compares the whole document with $expr, which keeps the check
+// atomic with the write.
+func versionOf(raw bson.Raw) (documentVersion, error) {
+	if field := versionField(); field != "" {
+		if value, err := raw.LookupErr(strings.Split(field, ".")...); err == nil {
+			var decoded interface{}
+			if err := value.Unmarshal(&decoded); err != nil {
+				return documentVersion{}, fmt.Errorf("failed to read %s: %w", field, err)
+			}
+
+			etag := hashETag(append([]byte{byte(value.Type)}, value.Value...))
+			if value.Type == bsontype.Int32 || value.Type == bsontype.Int64 {
+				etag = fmt.Sprintf(`"v%d"`, value.AsInt64())
+			}
+			return documentVersion{ETag: etag, Guard: bson.M{field: decoded}}, nil
+		}
+	}
+
+	var document bson.D
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return documentVersion{}, fmt.Errorf("failed to decode document: %w", err)
+	}
+	return documentVersion{
+		ETag: hashETag(raw),
+		// $literal keeps string values such as "$price" from being read as paths
+		Guard: bson.M{"$expr": bson.M{"$eq": bson.A{"$$ROOT", bson.M{"$literal": document}}}},
+	}, nil
+}
+
+// readVersion loads the current version of the document matching filter
+func readVersion(ctx context.Context, collection *mongo.Collection, filter bson.M) (documentVersion, error) {
+	raw, err := collection.FindOne(ctx, filter).DecodeBytes()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return documentVersion{}, ErrDocumentNotFound
+	}
+	if err != nil {
+		return documentVersion{}, fmt.Errorf("failed to get document: %w", err)
+	}
+	return versionOf(raw)
+}
+
+// guardFilter checks If-Match against the current document and returns the
+// filter that matches only that version. A missing document fails the
+// precondition, as HTTP requires.
+func guardFilter(ctx context.Context, collection *mongo.Collection, filter bson.M, ifMatch string) (bson.M, error) {
+	version, err := readVersion(ctx, collection, filter)
+	if errors.Is(err, ErrDocumentNotFound) {
+		return nil, fmt.Errorf("%w: document does not exist", ErrPreconditionFailed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !etagMatches(ifMatch, version.ETag) {
+		return nil, fmt.Errorf("%w: document has changed since it was read (current ETag %s)", ErrPreconditionFailed, version.ETag)
+	}
+
+	guarded := bson.M{}
+	for key, value := range filter {
+		guarded[key] = value
+	}
+	for key, value := range version.Guard {
+		guarded[key] = value
+	}
+	return guarded, nil
+}
+
+// etagMatches evaluates an If-Match header: "*" or a comma separated list.
+// If-Match uses strong comparison, so weak (W/) tags never match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func hashETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// stampVersion starts the _v counter on a new document
+func stampVersion(document map[string]interface{}) {
+	if autoVersion() {
+		document[versionCounterField] = 1
+	}
+}
+
+// versionedUpdate adds the _v increment to an update document when the
+// counter is maintained. Replacements become update pipelines so _v is
+// carried over and incremented atomically; the second result reports whether
+// update is still a plain replacement.
+func versionedUpdate(update interface{}, replace bool) (interface{}, bool, error) {
+	if !autoVersion() {
+		return update, replace, nil
+	}
+
+	if replace {
+		replacement, ok := update.(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("unexpected replacement type %T", update)
+		}
+		return versionedReplacement(replacement), false, nil
+	}
+
+	operators, ok := update.(bson.D)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected update type %T", update)
+	}
+	bumped, err := withVersionBump(operators)
+	return bumped, false, err
+}
+
+// withVersionBump adds {$inc: {_v: 1}} to an update document. Clients may not
+// set _v themselves.
+func withVersionBump(update bson.D) (bson.D, error) {
+	bumped := make(bson.D, 0, len(update)+1)
+	incremented := false
+	for _, op := range update {
+		fields := updateFields(op.Value)
+		for _, field := range fields {
+			if field.Key == versionCounterField || strings.HasPrefix(field.Key, versionCounterField+".") {
+				return nil, fmt.Errorf("%w: %s is maintained automatically", utils.ErrInvalidUpdate, versionCounterField)
+			}
+		}
+		if op.Key == "$inc" {
+			op = bson.E{Key: op.Key, Value: append(fields, bson.E{Key: versionCounterField, Value: 1})}
+			incremented = true
+		}
+		bumped = append(bumped, op)
+	}
+	if !incremented {
+		bumped = append(bumped, bson.E{Key: "$inc", Value: bson.D{{Key: versionCounterField, Value: 1}}})
+	}
+	return bumped, nil
+}
+
+// updateFields copies the field/value pairs of one update operator
+func updateFields(value interface{}) bson.D {
+	var fields bson.D
+	switch v := value.(type) {
+	case bson.D:
+		fields = append(fields, v...)
+	case bson.M:
+		for key, item := range v {
+			fields = append(fields, bson.E{Key: key, Value: item})
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			fields = append(fields, bson.E{Key: key, Value: item})
+		}
+	}
+	return fields
+}
+
+// versionedReplacement turns a replacement into an update pipeline that
+// keeps _id and increments _v
+func versionedReplacement(replacement map[string]interface{}) mongo.Pipeline {
+	delete(replacement, versionCounterField)
+	return mongo.Pipeline{{{Key: "$replaceWith", Value: bson.M{"$mergeObjects": bson.A{
+		bson.M{"$literal": replacement},
+		bson.M{
+			"_id":               "$_id",
+			versionCounterField: bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + versionCounterField, 0}}, 1}},
+		},
+	}}}}}
+}
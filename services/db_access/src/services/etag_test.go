@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/configs"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// setTestEnv installs a configuration with the given fields set. The env type
+// is unexported, so it is built through reflection.
+func setTestEnv(t *testing.T, fields map[string]interface{}) {
+	t.Helper()
+
+	saved := configs.Env
+	t.Cleanup(func() { configs.Env = saved })
+
+	env := reflect.New(reflect.TypeOf(configs.Env).Elem())
+	for name, value := range fields {
+		env.Elem().FieldByName(name).Set(reflect.ValueOf(value))
+	}
+	reflect.ValueOf(&configs.Env).Elem().Set(env)
+}
+
+func mustMarshal(t *testing.T, doc interface{}) bson.Raw {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("bson.Marshal returned error: %v", err)
+	}
+	return raw
+}
+
+func TestVersionOf(t *testing.T) {
+	doc := bson.D{{Key: "_id", Value: 1}, {Key: "name", Value: "Ada"}, {Key: "rev", Value: int64(7)}, {Key: "meta", Value: bson.D{{Key: "tag", Value: "x"}}}}
+
+	tests := []struct {
+		name      string
+		env       map[string]interface{}
+		wantETag  string
+		wantGuard bson.M
+	}{
+		{"integer version field", map[string]interface{}{"DocumentVersionField": "rev"}, `"v7"`, bson.M{"rev": int64(7)}},
+		{"auto version counter", map[string]interface{}{"DocumentAutoVersion": true}, "", nil},
+		{"nested string version field", map[string]interface{}{"DocumentVersionField": "meta.tag"}, "", bson.M{"meta.tag": "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, tt.env)
+			version, err := versionOf(mustMarshal(t, doc))
+			if err != nil {
+				t.Fatalf("versionOf returned error: %v", err)
+			}
+			if tt.wantETag != "" && version.ETag != tt.wantETag {
+				t.Errorf("ETag = %s, want %s", version.ETag, tt.wantETag)
+			}
+			if tt.wantGuard != nil && !reflect.DeepEqual(version.Guard, tt.wantGuard) {
+				t.Errorf("Guard = %#v, want %#v", version.Guard, tt.wantGuard)
+			}
+			if _, hashed := version.Guard["$expr"]; tt.wantGuard != nil && hashed {
+				t.Errorf("Guard compares the whole document despite a version field")
+			}
+		})
+	}
+}
+
+func TestVersionOfHashesWholeDocument(t *testing.T) {
+	setTestEnv(t, nil)
+
+	first, err := versionOf(mustMarshal(t, bson.D{{Key: "_id", Value: 1}, {Key: "price", Value: "$price"}}))
+	if err != nil {
+		t.Fatalf("versionOf returned error: %v", err)
+	}
+	same, _ := versionOf(mustMarshal(t, bson.D{{Key: "_id", Value: 1}, {Key: "price", Value: "$price"}}))
+	changed, _ := versionOf(mustMarshal(t, bson.D{{Key: "_id", Value: 1}, {Key: "price", Value: "$cost"}}))
+
+	if first.ETag != same.ETag {
+		t.Errorf("identical documents have ETags %s and %s", first.ETag, same.ETag)
+	}
+	if first.ETag == changed.ETag {
+		t.Errorf("changed document kept ETag %s", first.ETag)
+	}
+
+	want := bson.M{"$expr": bson.M{"$eq": bson.A{"$$ROOT", bson.M{"$literal": bson.D{{Key: "_id", Value: int32(1)}, {Key: "price", Value: "$price"}}}}}}
+	if !reflect.DeepEqual(first.Guard, want) {
+		t.Errorf("Guard = %#v, want %#v", first.Guard, want)
+	}
+}
+
+func TestVersionOfFallsBackWithoutField(t *testing.T) {
+	setTestEnv(t, map[string]interface{}{"DocumentVersionField": "rev"})
+
+	version, err := versionOf(mustMarshal(t, bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		t.Fatalf("versionOf returned error: %v", err)
+	}
+	if _, ok := version.Guard["$expr"]; !ok {
+		t.Errorf("Guard = %#v, want a whole-document comparison", version.Guard)
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		match  bool
+	}{
+		{`"v3"`, `"v3"`, true},
+		{`"v2"`, `"v3"`, false},
+		{`*`, `"v3"`, true},
+		{`"v1", "v3"`, `"v3"`, true},
+		{`"v1","v2"`, `"v3"`, false},
+		{``, `"v3"`, false},
+		{`v3`, `"v3"`, false},
+		{`W/"v3"`, `"v3"`, false},
+		{`W/"v1", W/"v3"`, `"v3"`, false},
+		{`W/"v1", "v3"`, `"v3"`, true},
+		{` "v1" , "v3" `, `"v3"`, true},
+		{`"v1", *`, `"v3"`, true},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, tt.etag); got != tt.match {
+			t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.header, tt.etag, got, tt.match)
+		}
+	}
+}
+
+func TestWithVersionBump(t *testing.T) {
+	tests := []struct {
+		name   string
+		update bson.D
+		want   bson.D
+	}{
+		{
+			name:   "adds $inc",
+			update: bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: "done"}}}},
+			want: bson.D{
+				{Key: "$set", Value: bson.D{{Key: "status", Value: "done"}}},
+				{Key: "$inc", Value: bson.D{{Key: "_v", Value: 1}}},
+			},
+		},
+		{
+			name:   "extends an existing $inc",
+			update: bson.D{{Key: "$inc", Value: bson.D{{Key: "views", Value: 1}}}},
+			want:   bson.D{{Key: "$inc", Value: bson.D{{Key: "views", Value: 1}, {Key: "_v", Value: 1}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withVersionBump(tt.update)
+			if err != nil {
+				t.Fatalf("withVersionBump returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withVersionBump = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithVersionBumpRejectsClientVersion(t *testing.T) {
+	for _, update := range []bson.D{
+		{{Key: "$set", Value: bson.D{{Key: "_v", Value: 1}}}},
+		{{Key: "$unset", Value: bson.D{{Key: "_v", Value: ""}}}},
+		{{Key: "$inc", Value: bson.D{{Key: "_v", Value: -1}}}},
+		{{Key: "$set", Value: bson.D{{Key: "_v.sub", Value: 1}}}},
+	} {
+		if _, err := withVersionBump(update); !errors.Is(err, utils.ErrInvalidUpdate) {
+			t.Errorf("withVersionBump(%v) error = %v, want ErrInvalidUpdate", update, err)
+		}
+	}
+}
+
+func TestVersionedUpdate(t *testing.T) {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "a", Value: 1}}}}
+	replacement := map[string]interface{}{"a": 1, "_v": 9}
+
+	t.Run("disabled", func(t *testing.T) {
+		setTestEnv(t, nil)
+		got, replace, err := versionedUpdate(update, false)
+		if err != nil || replace || !reflect.DeepEqual(got, update) {
+			t.Errorf("versionedUpdate = %v, %v, %v; want the update unchanged", got, replace, err)
+		}
+		got, replace, err = versionedUpdate(replacement, true)
+		if err != nil || !replace || !reflect.DeepEqual(got, replacement) {
+			t.Errorf("versionedUpdate = %v, %v, %v; want the replacement unchanged", got, replace, err)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		setTestEnv(t, map[string]interface{}{"DocumentAutoVersion": true})
+		got, replace, err := versionedUpdate(update, false)
+		if err != nil || replace {
+			t.Fatalf("versionedUpdate = %v, %v, %v", got, replace, err)
+		}
+		if bumped := got.(bson.D); len(bumped) != 2 || bumped[1].Key != "$inc" {
+			t.Errorf("versionedUpdate = %v, want a $inc of _v", got)
+		}
+	})
+
+	t.Run("replacement", func(t *testing.T) {
+		setTestEnv(t, map[string]interface{}{"DocumentAutoVersion": true})
+		got, replace, err := versionedUpdate(map[string]interface{}{"a": 1, "_v": 9}, true)
+		if err != nil || replace {
+			t.Fatalf("versionedUpdate = %v, %v, %v", got, replace, err)
+		}
+		want := mongo.Pipeline{{{Key: "$replaceWith", Value: bson.M{"$mergeObjects": bson.A{
+			bson.M{"$literal": map[string]interface{}{"a": 1}},
+			bson.M{"_id": "$_id", "_v": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$_v", 0}}, 1}}},
+		}}}}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("versionedUpdate = %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("unexpected types", func(t *testing.T) {
+		setTestEnv(t, map[string]interface{}{"DocumentAutoVersion": true})
+		if _, _, err := versionedUpdate(bson.M{"$set": 1}, false); err == nil {
+			t.Error("versionedUpdate accepted a bson.M update")
+		}
+		if _, _, err := versionedUpdate(bson.D{}, true); err == nil {
+			t.Error("versionedUpdate accepted a bson.D replacement")
+		}
+	})
+}
+
+func TestStampVersion(t *testing.T) {
+	setTestEnv(t, map[string]interface{}{"DocumentAutoVersion": true})
+	doc := map[string]interface{}{"a": 1}
+	stampVersion(doc)
+	if doc["_v"] != 1 {
+		t.Errorf("stampVersion set _v = %v, want 1", doc["_v"])
+	}
+
+	setTestEnv(t, nil)
+	doc = map[string]interface{}{"a": 1}
+	stampVersion(doc)
+	if _, ok := doc["_v"]; ok {
+		t.Error("stampVersion set _v with auto versioning off")
+	}
+}
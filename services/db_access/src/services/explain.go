@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Collections at least this large get a warning when queried by COLLSCAN
+const collscanWarnDocuments = 10000
+
+// explainPage explains the find findPage would run for req
+func explainPage(ctx context.Context, collection *mongo.Collection, filter bson.M, req pageRequest) (*models.QueryExplain, error) {
+	query, sort, projection, skip, err := planPage(filter, req)
+	if err != nil {
+		return nil, err
+	}
+	return explainFind(ctx, collection, query, sort, projection, req.Limit+1, skip)
+}
+
+// explainFind explains a find command with executionStats verbosity, which
+// runs the query without returning its documents, so it gets the same
+// maxTimeMS as the find itself
+func explainFind(ctx context.Context, collection *mongo.Collection, filter bson.M, sort bson.D, projection bson.M, limit, skip int) (*models.QueryExplain, error) {
+	find := bson.D{
+		{Key: "find", Value: collection.Name()},
+		{Key: "filter", Value: filter},
+		{Key: "limit", Value: limit},
+		{Key: "skip", Value: skip},
+	}
+	if len(sort) > 0 {
+		find = append(find, bson.E{Key: "sort", Value: sort})
+	}
+	if projection != nil {
+		find = append(find, bson.E{Key: "projection", Value: projection})
+	}
+	return runExplain(ctx, collection, "find", find, filteredReadMaxTime())
+}
+
+// explainAggregate explains an aggregation pipeline under the limits the
+// aggregation itself would run with
+func explainAggregate(ctx context.Context, collection *mongo.Collection, pipeline bson.A, maxTime time.Duration) (*models.QueryExplain, error) {
+	return runExplain(ctx, collection, "aggregate", bson.D{
+		{Key: "aggregate", Value: collection.Name()},
+		{Key: "pipeline", Value: pipeline},
+		{Key: "allowDiskUse", Value: false},
+		{Key: "cursor", Value: bson.D{}},
+	}, maxTime)
+}
+
+// explainEntriesResponse wraps a plan in the listing response shape
+func explainEntriesResponse(dbName, collectionName string, limit, skip int, plan *models.QueryExplain) *models.CollectionEntriesResponse {
+	return &models.CollectionEntriesResponse{
+		Message:    "Query plan retrieved successfully",
+		Database:   dbName,
+		Collection: collectionName,
+		Data:       []bson.M{},
+		TotalCount: -1,
+		Limit:      limit,
+		Skip:       skip,
+		Explain:    plan,
+		Code:       0,
+	}
+}
+
+// runExplain runs the explain command and reduces its output, which varies
+// between server versions, query engines and topologies, to QueryExplain.
+// executionStats really executes the command, so maxTime bounds it.
+func runExplain(ctx context.Context, collection *mongo.Collection, operation string, command bson.D, maxTime time.Duration) (*models.QueryExplain, error) {
+	var output bson.M
+	err := collection.Database().RunCommand(ctx, bson.D{
+		{Key: "explain", Value: command},
+		{Key: "verbosity", Value: "executionStats"},
+		{Key: "maxTimeMS", Value: maxTime.Milliseconds()},
+	}).Decode(&output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain %s: %w", operation, err)
+	}
+
+	explain := summarizeExplain(output)
+	explain.Operation = operation
+
+	estimated, err := collection.EstimatedDocumentCount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate document count: %w", err)
+	}
+	explain.EstimatedDocuments = estimated
+
+	if explain.CollectionScan && estimated >= collscanWarnDocuments {
+		explain.Warnings = append(explain.Warnings, fmt.Sprintf("COLLSCAN over a collection of about %d documents; add an index on the filtered and sorted fields", estimated))
+	}
+	if explain.DocsExamined > 1000 && explain.DocsExamined > 100*explain.DocsReturned {
+		explain.Warnings = append(explain.Warnings, fmt.Sprintf("examined %d documents to return %d; the query is not selective on any index", explain.DocsExamined, explain.DocsReturned))
+	}
+
+	return explain, nil
+}
+
+// summarizeExplain extracts the plan and execution statistics from raw
+// explain output. Aggregations report the query under a $cursor stage and
+// sharded clusters per shard, so sections are found by searching.
+func summarizeExplain(output bson.M) *models.QueryExplain {
+	explain := &models.QueryExplain{
+		IndexesUsed: []string{},
+		Warnings:    []string{},
+	}
+
+	if planner, ok := findSection(output, "queryPlanner"); ok {
+		if plan, ok := planner["winningPlan"].(bson.M); ok {
+			// The slot based engine nests the classic tree under queryPlan
+			if tree, ok := plan["queryPlan"].(bson.M); ok {
+				plan = tree
+			}
+			explain.WinningPlan = describePlan(plan, explain)
+		}
+	}
+
+	if stats, ok := findSection(output, "executionStats"); ok {
+		explain.DocsExamined = toInt64(stats["totalDocsExamined"])
+		explain.KeysExamined = toInt64(stats["totalKeysExamined"])
+		explain.DocsReturned = toInt64(stats["nReturned"])
+		explain.ExecutionTimeMS = toInt64(stats["executionTimeMillis"])
+	}
+
+	// Stages after $cursor ran in the aggregation framework; the last one
+	// reports what the pipeline returned
+	if stages, ok := output["stages"].(bson.A); ok {
+		for _, item := range stages {
+			stage, ok := item.(bson.M)
+			if !ok {
+				continue
+			}
+			for name := range stage {
+				if strings.HasPrefix(name, "$") && name != "$cursor" {
+					explain.PipelineStages = append(explain.PipelineStages, name)
+				}
+			}
+			if n, ok := stage["nReturned"]; ok {
+				explain.DocsReturned = toInt64(n)
+			}
+			if ms, ok := stage["executionTimeMillisEstimate"]; ok && toInt64(ms) > explain.ExecutionTimeMS {
+				explain.ExecutionTimeMS = toInt64(ms)
+			}
+		}
+	}
+
+	return explain
+}
+
+// describePlan renders a plan tree as "LIMIT > FETCH > IXSCAN", with
+// branches of OR-like stages in brackets, and records index usage
+func describePlan(node bson.M, explain *models.QueryExplain) string {
+	stage, _ := node["stage"].(string)
+	if stage == "COLLSCAN" {
+		explain.CollectionScan = true
+	}
+	if index, ok := node["indexName"].(string); ok && !containsString(explain.IndexesUsed, index) {
+		explain.IndexesUsed = append(explain.IndexesUsed, index)
+	}
+
+	// Sharded plans list each shard's own winning plan
+	if shards, ok := node["shards"].(bson.A); ok {
+		branches := make([]string, 0, len(shards))
+		for _, item := range shards {
+			shard, ok := item.(bson.M)
+			if !ok {
+				continue
+			}
+			if plan, ok := shard["winningPlan"].(bson.M); ok {
+				if tree, ok := plan["queryPlan"].(bson.M); ok {
+					plan = tree
+				}
+				branches = append(branches, describePlan(plan, explain))
+			}
+		}
+		return stage + " > [" + strings.Join(branches, ", ") + "]"
+	}
+
+	if input, ok := node["inputStage"].(bson.M); ok {
+		return stage + " > " + describePlan(input, explain)
+	}
+	if inputs, ok := node["inputStages"].(bson.A); ok {
+		branches := make([]string, 0, len(inputs))
+		for _, item := range inputs {
+			if input, ok := item.(bson.M); ok {
+				branches = append(branches, describePlan(input, explain))
+			}
+		}
+		return stage + " > [" + strings.Join(branches, ", ") + "]"
+	}
+	return stage
+}
+
+// findSection returns the first document stored under key, searching nested
+// documents and arrays depth first
+func findSection(value interface{}, key string) (bson.M, bool) {
+	switch v := value.(type) {
+	case bson.M:
+		if section, ok := v[key].(bson.M); ok {
+			return section, true
+		}
+		for _, child := range v {
+			if section, ok := findSection(child, key); ok {
+				return section, true
+			}
+		}
+	case bson.A:
+		for _, child := range v {
+			if section, ok := findSection(child, key); ok {
+				return section, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
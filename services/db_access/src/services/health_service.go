@@ -0,0 +1,97 @@
+package services
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/configs"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/mongodb"
+)
+
+// ready tracks whether the service should receive traffic. It starts true and
+// flips to false once shutdown begins.
+var ready atomic.Bool
+
+// startedAt is used to report uptime
+var startedAt = time.Now()
+
+func init() {
+	ready.Store(true)
+}
+
+type HealthService struct{}
+
+func NewHealthService() *HealthService {
+	return &HealthService{}
+}
+
+// SetReady marks the service as ready or not ready for traffic
+func SetReady(isReady bool) {
+	ready.Store(isReady)
+}
+
+// IsReady reports whether the service is accepting new traffic
+func (s *HealthService) IsReady() bool {
+	return ready.Load()
+}
+
+// Liveness reports that the process is up; it checks no dependencies
+func (s *HealthService) Liveness() models.LivenessResponse {
+	return models.LivenessResponse{
+		Status:        "ok",
+		UptimeSeconds: int64(time.Since(startedAt).Seconds()),
+	}
+}
+
+// Readiness reports whether the service should receive traffic along with
+// build, runtime and cached MongoDB client details. Cached clients point at
+// user databases, so their state is informational and never fails readiness.
+func (s *HealthService) Readiness() (models.ReadinessResponse, bool) {
+	response := models.ReadinessResponse{
+		Status: "ready",
+		Config: models.ConfigStatus{Valid: true},
+		Build: models.BuildInfo{
+			Version:   configs.Version,
+			Commit:    configs.Commit,
+			BuildTime: configs.BuildTime,
+			GoVersion: runtime.Version(),
+		},
+		StartedAt:     startedAt,
+		UptimeSeconds: int64(time.Since(startedAt).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		MongoClients:  []models.MongoClientStatus{},
+	}
+
+	isReady := s.IsReady()
+	if configs.Env == nil {
+		response.Config = models.ConfigStatus{Valid: false, Error: "configuration not loaded"}
+		isReady = false
+	} else if err := configs.Env.Validate(); err != nil {
+		response.Config = models.ConfigStatus{Valid: false, Error: err.Error()}
+		isReady = false
+	}
+
+	for _, client := range mongodb.CachedClientStatuses() {
+		status := models.MongoClientStatus{
+			ID:                client.ID,
+			State:             client.State,
+			ActiveUsers:       client.ActiveUsers,
+			PoolInUse:         client.InUse,
+			PoolIdle:          client.Idle,
+			LastUsed:          client.LastUsed,
+			LastPingLatencyMS: float64(client.LastPingLatency.Microseconds()) / 1000,
+		}
+		if !client.LastPingAt.IsZero() {
+			lastPingAt := client.LastPingAt
+			status.LastPingAt = &lastPingAt
+		}
+		response.MongoClients = append(response.MongoClients, status)
+	}
+
+	if !isReady {
+		response.Status = "not ready"
+	}
+	return response, isReady
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidCursor is returned when a next_cursor token is malformed, was
+// tampered with, or belongs to a different collection, filter or sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidPagination is returned for unusable count modes
+var ErrInvalidPagination = errors.New("invalid pagination")
+
+// Page size limits shared by every listing endpoint
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 1000
+)
+
+// Count modes for the total reported alongside a page
+const (
+	countExact     = "exact"     // CountDocuments with the filter
+	countEstimated = "estimated" // collection metadata, only without a filter
+	countNone      = "none"      // total_count is -1
+)
+
+// pageRequest describes one page of a document listing
+type pageRequest struct {
+	Namespace  string // "db.collection", binds cursors to a collection
+	Filter     string // raw filter parameter, binds cursors to a filter
+	Sort       bson.D // from parseSortAndProjection
+	Projection bson.M
+	Limit      int
+	Skip       int
+	Cursor     string
+}
+
+// pageResult is one page of documents. NextCursor is set whenever HasMore is.
+type pageResult struct {
+	Documents  []bson.M
+	HasMore    bool
+	NextCursor string
+	Skip       int
+}
+
+// cursorToken is the signed payload behind next_cursor
+type cursorToken struct {
+	Namespace  string   `json:"ns"`
+	FilterHash string   `json:"fh"`
+	Sort       []string `json:"s"`
+	Values     string   `json:"k"` // canonical Extended JSON of the last sort key values
+}
+
+// findPage runs a paginated find. Results are always ordered by the requested
+// sort plus _id, so keyset cursors and skip pages see the same order. One
+// extra document is fetched to report HasMore without counting.
+func findPage(ctx context.Context, collection *mongo.Collection, filter bson.M, req pageRequest) (*pageResult, error) {
+	query, sort, projection, skip, err := planPage(filter, req)
+	if err != nil {
+		return nil, err
+	}
+
+	findOptions := options.Find().
+		SetSort(sort).
+		SetLimit(int64(req.Limit + 1)).
+		SetSkip(int64(skip)).
+		SetMaxTime(filteredReadMaxTime())
+	if projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
+	cursor, err := collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	documents := []bson.M{}
+	if err = cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode documents: %v", err)
+	}
+
+	result := &pageResult{Documents: documents, Skip: skip}
+	if len(documents) > req.Limit {
+		result.Documents = documents[:req.Limit]
+		result.HasMore = true
+
+		next, err := encodeCursor(req, sort, result.Documents[req.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = next
+	}
+
+	return result, nil
+}
+
+// planPage resolves the filter, sort, projection and skip findPage runs with
+func planPage(filter bson.M, req pageRequest) (bson.M, bson.D, bson.M, int, error) {
+	sort := withIDTiebreaker(req.Sort)
+	projection := withSortFields(req.Projection, sort)
+
+	if req.Cursor == "" {
+		return filter, sort, projection, req.Skip, nil
+	}
+
+	values, err := decodeCursor(req.Cursor, req, sort)
+	if err != nil {
+		return nil, nil, nil, 0, err
+	}
+	// The cursor already marks the position, so skip is ignored
+	return andFilters(filter, keysetFilter(sort, values)), sort, projection, 0, nil
+}
+
+// pageLimit applies the default page size and the server-side cap
+func pageLimit(limit int) int {
+	if limit < 1 {
+		return defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return limit
+}
+
+// countTotal returns the total for a listing and whether it is an estimate.
+// Without an explicit mode, first pages are counted exactly and cursor pages
+// are not counted at all.
+func countTotal(ctx context.Context, collection *mongo.Collection, filter bson.M, mode string, cursorPage bool) (int64, bool, error) {
+	if mode == "" {
+		mode = countExact
+		if cursorPage {
+			mode = countNone
+		}
+	}
+
+	switch mode {
+	case countExact:
+		total, err := collection.CountDocuments(ctx, filter, options.Count().SetMaxTime(filteredReadMaxTime()))
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to count documents: %v", err)
+		}
+		return total, false, nil
+	case countEstimated:
+		if len(filter) > 0 {
+			return 0, false, fmt.Errorf("%w: estimated counts cannot apply a filter, count exactly instead", ErrInvalidPagination)
+		}
+		total, err := collection.EstimatedDocumentCount(ctx)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to estimate document count: %v", err)
+		}
+		return total, true, nil
+	case countNone:
+		return -1, false, nil
+	default:
+		return 0, false, fmt.Errorf("%w: count must be exact, estimated or none (got %q)", ErrInvalidPagination, mode)
+	}
+}
+
+// withIDTiebreaker appends _id to the sort unless it is already present, which
+// makes the order total so a cursor position is unambiguous
+func withIDTiebreaker(sort bson.D) bson.D {
+	for _, key := range sort {
+		if key.Key == "_id" {
+			return sort
+		}
+	}
+	return append(append(bson.D{}, sort...), bson.E{Key: "_id", Value: 1})
+}
+
+// withSortFields makes sure a projection keeps the sort fields, which the
+// next cursor is built from
+func withSortFields(projection bson.M, sort bson.D) bson.M {
+	if projection == nil {
+		return nil
+	}
+
+	inclusive := false
+	for field, value := range projection {
+		if value == 1 && field != "_id" {
+			inclusive = true
+		}
+	}
+
+	for _, key := range sort {
+		if !inclusive {
+			// Drop any exclusion that would hide the sort field
+			for field := range projection {
+				if field == key.Key || strings.HasPrefix(key.Key, field+".") || strings.HasPrefix(field, key.Key+".") {
+					delete(projection, field)
+				}
+			}
+			continue
+		}
+
+		covered := false
+		for field, value := range projection {
+			if value == 1 && (field == key.Key || strings.HasPrefix(key.Key, field+".")) {
+				covered = true
+			}
+		}
+		if covered {
+			continue
+		}
+		for field := range projection {
+			if strings.HasPrefix(field, key.Key+".") {
+				delete(projection, field)
+			}
+		}
+		projection[key.Key] = 1
+	}
+
+	return projection
+}
+
+// keysetFilter matches documents strictly after values in sort order. Missing
+// and null values sort first in MongoDB, so they need explicit branches.
+func keysetFilter(sort bson.D, values []interface{}) bson.M {
+	branches := bson.A{}
+	for i, key := range sort {
+		branch := bson.M{}
+		for j := 0; j < i; j++ {
+			branch[sort[j].Key] = values[j]
+		}
+
+		value := values[i]
+		ascending := key.Value == 1
+		switch {
+		case value == nil && ascending:
+			branch[key.Key] = bson.M{"$ne": nil}
+		case value == nil:
+			continue // nothing sorts below null
+		case ascending:
+			branch[key.Key] = bson.M{"$gt": value}
+		default:
+			branch["$or"] = bson.A{
+				bson.M{key.Key: bson.M{"$lt": value}},
+				bson.M{key.Key: nil},
+			}
+		}
+		branches = append(branches, branch)
+	}
+
+	if len(branches) == 0 {
+		// Only reachable when every key is null and descending: no more rows
+		return bson.M{"_id": bson.M{"$exists": false}}
+	}
+	return bson.M{"$or": branches}
+}
+
+// andFilters combines a user filter with an extra condition
+func andFilters(filter, extra bson.M) bson.M {
+	if len(filter) == 0 {
+		return extra
+	}
+	return bson.M{"$and": bson.A{filter, extra}}
+}
+
+// encodeCursor signs the sort key values of the last returned document
+func encodeCursor(req pageRequest, sort bson.D, last bson.M) (string, error) {
+	values := make(bson.A, 0, len(sort))
+	for _, key := range sort {
+		value, _ := lookupPath(last, key.Key)
+		values = append(values, value)
+	}
+
+	extJSON, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: values}}, true, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %v", err)
+	}
+
+	return utils.SignPayload(cursorToken{
+		Namespace:  req.Namespace,
+		FilterHash: filterHash(req.Filter),
+		Sort:       sortSignature(sort),
+		Values:     string(extJSON),
+	})
+}
+
+// decodeCursor verifies a cursor against the current request and returns the
+// sort key values it encodes
+func decodeCursor(token string, req pageRequest, sort bson.D) ([]interface{}, error) {
+	var payload cursorToken
+	if err := utils.VerifyPayload(token, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	if payload.Namespace != req.Namespace || payload.FilterHash != filterHash(req.Filter) {
+		return nil, fmt.Errorf("%w: cursor was issued for a different collection or filter", ErrInvalidCursor)
+	}
+	if strings.Join(payload.Sort, ",") != strings.Join(sortSignature(sort), ",") {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCursor)
+	}
+
+	var decoded bson.D
+	if err := bson.UnmarshalExtJSON([]byte(payload.Values), true, &decoded); err != nil || len(decoded) != 1 {
+		return nil, fmt.Errorf("%w: malformed cursor values", ErrInvalidCursor)
+	}
+	values, ok := decoded[0].Value.(primitive.A)
+	if !ok || len(values) != len(sort) {
+		return nil, fmt.Errorf("%w: malformed cursor values", ErrInvalidCursor)
+	}
+
+	return values, nil
+}
+
+// sortSignature renders a sort as ["-total", "_id"] for comparison
+func sortSignature(sort bson.D) []string {
+	signature := make([]string, 0, len(sort))
+	for _, key := range sort {
+		if key.Value == -1 {
+			signature = append(signature, "-"+key.Key)
+		} else {
+			signature = append(signature, key.Key)
+		}
+	}
+	return signature
+}
+
+// filterHash fingerprints the raw filter parameter
+func filterHash(filter string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(filter)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// lookupPath reads a dotted path from a decoded document
+func lookupPath(doc bson.M, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case bson.M:
+			current = node[segment]
+		case map[string]interface{}:
+			current = node[segment]
+		default:
+			return nil, false
+		}
+		if current == nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5f1b2c3d4e5f6a7b8c9d0e1f")
+	req := pageRequest{Namespace: "shop.orders", Filter: "status:paid"}
+	sort := withIDTiebreaker(bson.D{{Key: "total", Value: -1}, {Key: "customer.name", Value: 1}})
+	last := bson.M{
+		"_id":      objectID,
+		"total":    int64(250),
+		"customer": bson.M{"name": "Ada"},
+	}
+
+	token, err := encodeCursor(req, sort, last)
+	if err != nil {
+		t.Fatalf("encodeCursor returned error: %v", err)
+	}
+
+	values, err := decodeCursor(token, req, sort)
+	if err != nil {
+		t.Fatalf("decodeCursor returned error: %v", err)
+	}
+	want := []interface{}{int64(250), "Ada", objectID}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("decodeCursor = %#v, want %#v", values, want)
+	}
+}
+
+func TestCursorMissingSortValue(t *testing.T) {
+	req := pageRequest{Namespace: "shop.orders"}
+	sort := withIDTiebreaker(bson.D{{Key: "shipped_at", Value: 1}})
+
+	token, err := encodeCursor(req, sort, bson.M{"_id": "a"})
+	if err != nil {
+		t.Fatalf("encodeCursor returned error: %v", err)
+	}
+	values, err := decodeCursor(token, req, sort)
+	if err != nil {
+		t.Fatalf("decodeCursor returned error: %v", err)
+	}
+	if values[0] != nil || values[1] != "a" {
+		t.Errorf("decodeCursor = %#v, want [nil a]", values)
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	req := pageRequest{Namespace: "shop.orders", Filter: "status:paid"}
+	sort := withIDTiebreaker(bson.D{{Key: "total", Value: -1}})
+	token, err := encodeCursor(req, sort, bson.M{"_id": "a", "total": 10})
+	if err != nil {
+		t.Fatalf("encodeCursor returned error: %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		req   pageRequest
+		sort  bson.D
+	}{
+		{"garbage", "not-a-cursor", req, sort},
+		{"tampered signature", payload + "." + strings.Repeat("A", len(signature)), req, sort},
+		{"tampered payload", "e30." + signature, req, sort},
+		{"other collection", token, pageRequest{Namespace: "shop.users", Filter: req.Filter}, sort},
+		{"other database", token, pageRequest{Namespace: "admin.orders", Filter: req.Filter}, sort},
+		{"other filter", token, pageRequest{Namespace: req.Namespace, Filter: "status:open"}, sort},
+		{"dropped filter", token, pageRequest{Namespace: req.Namespace}, sort},
+		{"other direction", token, req, withIDTiebreaker(bson.D{{Key: "total", Value: 1}})},
+		{"other sort field", token, req, withIDTiebreaker(bson.D{{Key: "created", Value: -1}})},
+		{"extra sort key", token, req, withIDTiebreaker(bson.D{{Key: "total", Value: -1}, {Key: "name", Value: 1}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.token, tt.req, tt.sort); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestKeysetFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   bson.D
+		values []interface{}
+		want   bson.M
+	}{
+		{
+			name:   "ascending",
+			sort:   bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+			values: []interface{}{"Ada", 7},
+			want: bson.M{"$or": bson.A{
+				bson.M{"name": bson.M{"$gt": "Ada"}},
+				bson.M{"name": "Ada", "_id": bson.M{"$gt": 7}},
+			}},
+		},
+		{
+			name:   "descending includes nulls",
+			sort:   bson.D{{Key: "total", Value: -1}, {Key: "_id", Value: 1}},
+			values: []interface{}{10, 7},
+			want: bson.M{"$or": bson.A{
+				bson.M{"$or": bson.A{bson.M{"total": bson.M{"$lt": 10}}, bson.M{"total": nil}}},
+				bson.M{"total": 10, "_id": bson.M{"$gt": 7}},
+			}},
+		},
+		{
+			name:   "ascending after null",
+			sort:   bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+			values: []interface{}{nil, 7},
+			want: bson.M{"$or": bson.A{
+				bson.M{"name": bson.M{"$ne": nil}},
+				bson.M{"name": nil, "_id": bson.M{"$gt": 7}},
+			}},
+		},
+		{
+			name:   "descending past null",
+			sort:   bson.D{{Key: "total", Value: -1}},
+			values: []interface{}{nil},
+			want:   bson.M{"_id": bson.M{"$exists": false}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keysetFilter(tt.sort, tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keysetFilter = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
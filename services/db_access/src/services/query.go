@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// filterSampleSize is the number of documents sampled to detect field types
+// when coercing filter values
+const filterSampleSize = 100
+
+// buildFilter parses a user filter for collection. Field types are only
+// sampled when a string value actually needs coercing.
+func buildFilter(ctx context.Context, collection *mongo.Collection, raw string) (bson.M, error) {
+	var fieldTypes map[string]string
+	lookup := func(field string) string {
+		if fieldTypes == nil {
+			fieldTypes = sampleFieldTypes(ctx, collection)
+		}
+		return fieldTypes[field]
+	}
+
+	return utils.ParseFilter(raw, lookup)
+}
+
+// filteredReadMaxTime is the maxTimeMS of reads that apply a user filter.
+// Request deadlines only stop the driver waiting; the server keeps running the
+// operation, and $regex runs on MongoDB's backtracking engine, so the server
+// is told to give up too.
+func filteredReadMaxTime() time.Duration {
+	return models.DefaultContextConfig.MediumTimeout
+}
+
+// usesRegex reports whether a parsed filter contains a $regex condition
+func usesRegex(filter interface{}) bool {
+	switch v := filter.(type) {
+	case bson.M:
+		for key, value := range v {
+			if key == "$regex" || usesRegex(value) {
+				return true
+			}
+		}
+	case bson.A:
+		for _, item := range v {
+			if usesRegex(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sampleFieldTypes returns the dominant type of each field in a sample of the
+// collection. Sampling errors leave values uncoerced rather than failing.
+func sampleFieldTypes(ctx context.Context, collection *mongo.Collection) map[string]string {
+	fieldTypes := make(map[string]string)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetLimit(filterSampleSize))
+	if err != nil {
+		return fieldTypes
+	}
+	defer cursor.Close(ctx)
+
+	var documents []bson.M
+	if err := cursor.All(ctx, &documents); err != nil {
+		return fieldTypes
+	}
+
+	for field, info := range utils.AnalyzeSchema(documents) {
+		fieldTypes[field] = info.Type
+	}
+	return fieldTypes
+}
+
+// filterString accepts a filter given in a JSON body either as an object or
+// as a string holding JSON or compact syntax
+func filterString(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	if raw[0] != '"' {
+		return string(raw), nil
+	}
+
+	var filter string
+	if err := json.Unmarshal(raw, &filter); err != nil {
+		return "", fmt.Errorf("%w: %v", utils.ErrInvalidFilter, err)
+	}
+	return filter, nil
+}
+
+// parseSortAndProjection validates the sort and fields parameters
+func parseSortAndProjection(sortParam, fieldsParam string) (bson.D, bson.M, error) {
+	sort, err := utils.ParseSort(sortParam)
+	if err != nil {
+		return nil, nil, err
+	}
+	projection, err := utils.ParseProjection(fieldsParam)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sort, projection, nil
+}
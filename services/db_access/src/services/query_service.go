@@ -0,0 +1,572 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Search limits
+const (
+	maxSearchLength      = 256
+	maxRegexSearchFields = 20
+	searchScoreField     = "_score"
+)
+
+// maxAggregateResults caps the documents returned by an aggregation
+const maxAggregateResults = 1000
+
+// Distinct value limits
+const (
+	defaultDistinctLimit = 20
+	maxDistinctLimit     = 1000
+	maxDistinctFields    = 10
+)
+
+// Search modes reported in CollectionEntriesResponse.SearchMode
+const (
+	searchModeText  = "text"
+	searchModeRegex = "regex"
+)
+
+// QueryService runs read-only queries beyond plain listing: search,
+// aggregation, distinct values and counts
+type QueryService struct{}
+
+func NewQueryService() *QueryService {
+	return &QueryService{}
+}
+
+// Search finds documents matching a free-text query. Collections with a text
+// index use $text ordered by relevance; others fall back to a case-insensitive
+// regex over the string fields detected in a sample of documents.
+func (s *QueryService) Search(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, text string, query models.QueryParams) (*models.CollectionEntriesResponse, error) {
+	if !utils.IsValidDBName(dbName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
+	}
+
+	if !utils.IsValidCollectionName(collectionName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" || len(text) > maxSearchLength {
+		return nil, fmt.Errorf("%w: q must be between 1 and %d characters", ErrInvalidQuery, maxSearchLength)
+	}
+
+	limit, skip := pageLimit(query.Limit), query.Skip
+	if skip < 0 {
+		skip = 0
+	}
+	userSort, projection, err := parseSortAndProjection(query.Sort, query.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
+	defer cancel()
+
+	filter, err := buildFilter(ctx, collection, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	textIndexed, err := hasTextIndex(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := searchModeRegex
+	var searchFilter bson.M
+	if textIndexed {
+		mode = searchModeText
+		searchFilter = bson.M{"$text": bson.M{"$search": text}}
+	} else {
+		fields, err := stringFields(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		searchFilter = regexSearchFilter(fields, text)
+	}
+	filter = andFilters(filter, searchFilter)
+
+	// Relevance order has no stable key to resume from, so only skip works
+	byRelevance := mode == searchModeText && len(userSort) == 0
+	if byRelevance && query.Cursor != "" {
+		return nil, fmt.Errorf("%w: cursor pagination needs an explicit sort when searching by relevance, use skip", ErrInvalidPagination)
+	}
+	req := pageRequest{
+		Namespace:  dbName + "." + collectionName,
+		Filter:     query.Filter + "\x00" + text,
+		Sort:       userSort,
+		Projection: projection,
+		Limit:      limit,
+		Skip:       skip,
+		Cursor:     query.Cursor,
+	}
+
+	if query.Explain {
+		var plan *models.QueryExplain
+		if byRelevance {
+			projection, sort := relevanceOrder(projection)
+			plan, err = explainFind(ctx, collection, filter, sort, projection, limit+1, skip)
+		} else {
+			plan, err = explainPage(ctx, collection, filter, req)
+		}
+		if err != nil {
+			return nil, err
+		}
+		response := explainEntriesResponse(dbName, collectionName, limit, skip, plan)
+		response.SearchMode = mode
+		return response, nil
+	}
+
+	totalCount, estimated, err := countTotal(ctx, collection, filter, query.Count, query.Cursor != "")
+	if err != nil {
+		return nil, err
+	}
+
+	var page *pageResult
+	if byRelevance {
+		page, err = findByRelevance(ctx, collection, filter, projection, limit, skip)
+	} else {
+		page, err = findPage(ctx, collection, filter, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CollectionEntriesResponse{
+		Message:    "Search completed successfully",
+		Database:   dbName,
+		Collection: collectionName,
+		Data:       page.Documents,
+		Count:      len(page.Documents),
+		TotalCount: totalCount,
+		Limit:      limit,
+		Skip:       page.Skip,
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
+		Estimated:  estimated,
+		SearchMode: mode,
+		Code:       0,
+	}, nil
+}
+
+// Aggregate runs a pipeline that has been validated against the stage
+// allowlist. The server enforces maxTimeMS and the output is capped by an
+// appended $limit. max_time_ms defaults to SHORT_TIMEOUT and is capped at
+// MEDIUM_TIMEOUT.
+func (s *QueryService) Aggregate(ctx context.Context, dbName, collectionName string, req models.AggregateRequest) (*models.AggregateResponse, error) {
+	if !utils.IsValidDBName(dbName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
+	}
+
+	if !utils.IsValidCollectionName(collectionName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
+	}
+
+	if req.MaxTimeMS < 0 || req.Limit < 0 {
+		return nil, fmt.Errorf("%w: max_time_ms and limit cannot be negative", ErrInvalidQuery)
+	}
+
+	pipeline, err := utils.ParsePipeline(req.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit == 0 || limit > maxAggregateResults {
+		limit = maxAggregateResults
+	}
+	maxTime := models.DefaultContextConfig.ShortTimeout
+	if req.MaxTimeMS > 0 {
+		maxTime = time.Duration(req.MaxTimeMS) * time.Millisecond
+	}
+	if maxTime > models.DefaultContextConfig.MediumTimeout {
+		maxTime = models.DefaultContextConfig.MediumTimeout
+	}
+
+	// One extra document tells us whether the output was truncated
+	pipeline = append(pipeline, bson.D{{Key: "$limit", Value: limit + 1}})
+
+	client, release, err := acquireClient(req.ConnectionRef)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, req.ConsistencyOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
+	defer cancel()
+
+	if req.Explain {
+		plan, err := explainAggregate(ctx, collection, pipeline, maxTime)
+		if err != nil {
+			return nil, err
+		}
+		return &models.AggregateResponse{
+			Message:    "Query plan retrieved successfully",
+			Database:   dbName,
+			Collection: collectionName,
+			Data:       []bson.M{},
+			Explain:    plan,
+			Code:       0,
+		}, nil
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetMaxTime(maxTime).SetAllowDiskUse(false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run aggregation: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	documents := []bson.M{}
+	if err = cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode aggregation results: %w", err)
+	}
+
+	truncated := len(documents) > limit
+	if truncated {
+		documents = documents[:limit]
+	}
+
+	return &models.AggregateResponse{
+		Message:    "Aggregation completed successfully",
+		Database:   dbName,
+		Collection: collectionName,
+		Data:       documents,
+		Count:      len(documents),
+		Truncated:  truncated,
+		Code:       0,
+	}, nil
+}
+
+// DistinctValues returns the most frequent values of each field with their
+// document counts. Array values are counted per element, dotted paths reach
+// into embedded documents and arrays of them.
+func (s *QueryService) DistinctValues(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, fieldsParam, filterParam string, limit int) (*models.DistinctValuesResponse, error) {
+	if !utils.IsValidDBName(dbName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
+	}
+
+	if !utils.IsValidCollectionName(collectionName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(fieldsParam, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if !utils.IsValidFieldPath(field) {
+			return nil, fmt.Errorf("%w: invalid field name %q", ErrInvalidQuery, field)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 || len(fields) > maxDistinctFields {
+		return nil, fmt.Errorf("%w: fields must list between 1 and %d field names", ErrInvalidQuery, maxDistinctFields)
+	}
+
+	if limit < 1 {
+		limit = defaultDistinctLimit
+	}
+	if limit > maxDistinctLimit {
+		limit = maxDistinctLimit
+	}
+
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
+	defer cancel()
+
+	filter, err := buildFilter(ctx, collection, filterParam)
+	if err != nil {
+		return nil, err
+	}
+
+	// One $facet pass computes every field; facet names cannot hold dots, so
+	// fields are addressed by position
+	facets := bson.D{}
+	for i, field := range fields {
+		grouped := bson.A{
+			bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: 0}, {Key: "v", Value: "$" + field}}}},
+			// Twice: a path through an array of documents yields an array per
+			// document, whose elements may be arrays themselves
+			bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$v"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+			bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$v"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+			bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$v"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+		}
+		values := append(append(bson.A{}, grouped...),
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+			bson.D{{Key: "$limit", Value: limit}},
+		)
+		total := append(append(bson.A{}, grouped...), bson.D{{Key: "$count", Value: "n"}})
+
+		facets = append(facets,
+			bson.E{Key: fmt.Sprintf("v%d", i), Value: values},
+			bson.E{Key: fmt.Sprintf("n%d", i), Value: total},
+		)
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$facet", Value: facets}}}
+	if len(filter) > 0 {
+		pipeline = append(mongo.Pipeline{{{Key: "$match", Value: filter}}}, pipeline...)
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true).SetMaxTime(filteredReadMaxTime()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute distinct values: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode distinct values: %w", err)
+	}
+
+	response := &models.DistinctValuesResponse{
+		Message:    "Distinct values retrieved successfully",
+		Database:   dbName,
+		Collection: collectionName,
+		Fields:     make(map[string]models.FieldValueCounts, len(fields)),
+		Limit:      limit,
+		Code:       0,
+	}
+	for i, field := range fields {
+		counts := models.FieldValueCounts{Values: []models.ValueCount{}}
+		if len(results) > 0 {
+			counts.Values, counts.DistinctCount = valueCounts(results[0], i)
+		}
+		counts.Truncated = counts.DistinctCount > int64(len(counts.Values))
+		response.Fields[field] = counts
+	}
+
+	return response, nil
+}
+
+// valueCounts reads the v<i> and n<i> facets of a DistinctValues result
+func valueCounts(result bson.M, i int) ([]models.ValueCount, int64) {
+	values := []models.ValueCount{}
+	if groups, ok := result[fmt.Sprintf("v%d", i)].(bson.A); ok {
+		for _, group := range groups {
+			if doc, ok := group.(bson.M); ok {
+				values = append(values, models.ValueCount{Value: doc["_id"], Count: toInt64(doc["count"])})
+			}
+		}
+	}
+
+	var distinct int64
+	if totals, ok := result[fmt.Sprintf("n%d", i)].(bson.A); ok && len(totals) > 0 {
+		if doc, ok := totals[0].(bson.M); ok {
+			distinct = toInt64(doc["n"])
+		}
+	}
+	return values, distinct
+}
+
+// toInt64 converts the numeric types the server returns for counts
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// Count returns the number of documents matching a filter, or the estimate
+// from collection metadata, which cannot be filtered
+func (s *QueryService) Count(ctx context.Context, conn models.ConnectionRef, consistency models.ConsistencyOptions, dbName, collectionName, filterParam string, estimated bool) (*models.CountResponse, error) {
+	if !utils.IsValidDBName(dbName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabaseName, dbName)
+	}
+
+	if !utils.IsValidCollectionName(collectionName) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCollectionName, collectionName)
+	}
+
+	client, release, err := acquireClient(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	collection, err := collectionFor(client, dbName, collectionName, consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, models.DefaultContextConfig.MediumTimeout)
+	defer cancel()
+
+	filter, err := buildFilter(ctx, collection, filterParam)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := countExact
+	if estimated {
+		mode = countEstimated
+	}
+	count, estimated, err := countTotal(ctx, collection, filter, mode, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CountResponse{
+		Message:    "Documents counted successfully",
+		Database:   dbName,
+		Collection: collectionName,
+		Count:      count,
+		Estimated:  estimated,
+		Code:       0,
+	}, nil
+}
+
+// findByRelevance returns one page of $text results ordered by score, which is
+// included in each document as _score
+func findByRelevance(ctx context.Context, collection *mongo.Collection, filter, projection bson.M, limit, skip int) (*pageResult, error) {
+	projection, sort := relevanceOrder(projection)
+
+	findOptions := options.Find().
+		SetProjection(projection).
+		SetSort(sort).
+		SetLimit(int64(limit + 1)).
+		SetSkip(int64(skip)).
+		SetMaxTime(filteredReadMaxTime())
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search collection: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	documents := []bson.M{}
+	if err = cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode documents: %v", err)
+	}
+
+	page := &pageResult{Documents: documents, Skip: skip}
+	if len(documents) > limit {
+		page.Documents = documents[:limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+// relevanceOrder adds the text score to a projection and returns the sort
+// that orders by it
+func relevanceOrder(projection bson.M) (bson.M, bson.D) {
+	score := bson.M{"$meta": "textScore"}
+	if projection == nil {
+		projection = bson.M{}
+	}
+	projection[searchScoreField] = score
+	return projection, bson.D{{Key: searchScoreField, Value: score}, {Key: "_id", Value: 1}}
+}
+
+// hasTextIndex reports whether the collection has a text index
+func hasTextIndex(ctx context.Context, collection *mongo.Collection) (bool, error) {
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list indexes: %v", err)
+	}
+
+	for _, spec := range specs {
+		// Text indexes are stored with the internal _fts key
+		if _, err := spec.KeysDocument.LookupErr("_fts"); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// stringFields returns the fields that hold strings in a sample of documents
+func stringFields(ctx context.Context, collection *mongo.Collection) ([]string, error) {
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetLimit(filterSampleSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample documents: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var documents []bson.M
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, fmt.Errorf("failed to decode documents: %v", err)
+	}
+
+	schema := utils.AnalyzeEnhancedSchema(documents, len(documents))
+	var fields []string
+	for field, info := range schema {
+		if field != "_id" && info.AllTypes["string"] > 0 {
+			fields = append(fields, field)
+		}
+	}
+
+	// Prefer the most common fields when there are too many to search
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := schema[fields[i]].AllTypes["string"], schema[fields[j]].AllTypes["string"]
+		if a != b {
+			return a > b
+		}
+		return fields[i] < fields[j]
+	})
+	return fields, nil
+}
+
+// regexSearchFilter matches text literally and case-insensitively in any of
+// fields. No fields means nothing can match.
+func regexSearchFilter(fields []string, text string) bson.M {
+	if len(fields) == 0 {
+		return bson.M{"_id": bson.M{"$exists": false}}
+	}
+	if len(fields) > maxRegexSearchFields {
+		fields = fields[:maxRegexSearchFields]
+	}
+
+	pattern := regexp.QuoteMeta(text)
+	clauses := make(bson.A, 0, len(fields))
+	for _, field := range fields {
+		clauses = append(clauses, bson.M{field: bson.M{"$regex": pattern, "$options": "i"}})
+	}
+	return bson.M{"$or": clauses}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRegexSearchFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		text   string
+		want   bson.M
+	}{
+		{
+			name:   "no fields",
+			fields: nil,
+			text:   "ada",
+			want:   bson.M{"_id": bson.M{"$exists": false}},
+		},
+		{
+			name:   "plain text",
+			fields: []string{"name", "email"},
+			text:   "ada",
+			want: bson.M{"$or": bson.A{
+				bson.M{"name": bson.M{"$regex": "ada", "$options": "i"}},
+				bson.M{"email": bson.M{"$regex": "ada", "$options": "i"}},
+			}},
+		},
+		{
+			name:   "metacharacters are escaped",
+			fields: []string{"email"},
+			text:   "a.b+c@(x)*",
+			want:   bson.M{"$or": bson.A{bson.M{"email": bson.M{"$regex": `a\.b\+c@\(x\)\*`, "$options": "i"}}}},
+		},
+		{
+			name:   "backtracking pattern is literal",
+			fields: []string{"name"},
+			text:   "(a+)+$",
+			want:   bson.M{"$or": bson.A{bson.M{"name": bson.M{"$regex": `\(a\+\)\+\$`, "$options": "i"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := regexSearchFilter(tt.fields, tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("regexSearchFilter = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegexSearchFilterMatchesLiterally(t *testing.T) {
+	for _, text := range []string{"a.c", "50% off [sale]", `C:\path`, "^start", "x|y"} {
+		filter := regexSearchFilter([]string{"f"}, text)
+		pattern := filter["$or"].(bson.A)[0].(bson.M)["f"].(bson.M)["$regex"].(string)
+		re := regexp.MustCompile("(?i)" + pattern)
+
+		if !re.MatchString("prefix " + strings.ToUpper(text) + " suffix") {
+			t.Errorf("pattern %q does not match %q", pattern, text)
+		}
+	}
+
+	pattern := regexSearchFilter([]string{"f"}, "a.c")["$or"].(bson.A)[0].(bson.M)["f"].(bson.M)["$regex"].(string)
+	if regexp.MustCompile(pattern).MatchString("abc") {
+		t.Errorf("pattern %q treats . as a wildcard", pattern)
+	}
+}
+
+func TestRegexSearchFilterCapsFields(t *testing.T) {
+	fields := make([]string, maxRegexSearchFields+5)
+	for i := range fields {
+		fields[i] = fmt.Sprintf("f%d", i)
+	}
+
+	clauses := regexSearchFilter(fields, "x")["$or"].(bson.A)
+	if len(clauses) != maxRegexSearchFields {
+		t.Errorf("regexSearchFilter searched %d fields, want %d", len(clauses), maxRegexSearchFields)
+	}
+}
+
+func TestSearchRejectsBeforeConnecting(t *testing.T) {
+	service := NewQueryService()
+	// No connection is supplied, so reaching the database would fail with
+	// ErrConnectionRequired instead of the validation error
+	tests := []struct {
+		name     string
+		db, coll string
+		text     string
+		wantErr  error
+	}{
+		{"invalid database", "a/b", "orders", "ada", ErrInvalidDatabaseName},
+		{"system collection", "shop", "system.users", "ada", ErrInvalidCollectionName},
+		{"empty query", "shop", "orders", "   ", ErrInvalidQuery},
+		{"query too long", "shop", "orders", strings.Repeat("a", maxSearchLength+1), ErrInvalidQuery},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Search(context.Background(), models.ConnectionRef{}, models.ConsistencyOptions{}, tt.db, tt.coll, tt.text, models.QueryParams{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Search error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/src/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Documents fetched per getMore while streaming
+const streamBatchSize = 500
+
+// DocumentEmitter receives streamed documents one at a time. Returning an
+// error, e.g. because the client went away, stops the stream.
+type DocumentEmitter func(doc bson.M) error
+
+// streamPage runs the same query as findPage but hands documents to emit as
+// they come off the cursor, so memory use does not grow with the result. A
+// zero limit streams every matching document.
+func streamPage(ctx context.Context, collection *mongo.Collection, filter bson.M, req pageRequest, emit DocumentEmitter) error {
+	query, sort, projection, skip, err := planPage(filter, req)
+	if err != nil {
+		return err
+	}
+
+	findOptions := options.Find().
+		SetSort(sort).
+		SetSkip(int64(skip)).
+		SetBatchSize(streamBatchSize)
+	if req.Limit > 0 {
+		findOptions.SetLimit(int64(req.Limit))
+	}
+	if projection != nil {
+		findOptions.SetProjection(projection)
+	}
+	// Exports may legitimately run long, so only regex filters, which can
+	// backtrack without bound, get a server-side limit
+	if usesRegex(query) {
+		findOptions.SetMaxTime(models.DefaultContextConfig.LongTimeout)
+	}
+
+	// There is no overall deadline; the stream is cancelled when the server
+	// produces nothing for streamIdleTimeout. The clock is paused while emit
+	// writes to a slow client.
+	idleTimeout := streamIdleTimeout()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(idleTimeout, func() {
+		cancel(fmt.Errorf("%w: no documents received for %s", context.DeadlineExceeded, idleTimeout))
+	})
+	defer idle.Stop()
+
+	cursor, err := collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return fmt.Errorf("failed to query collection: %w", streamError(ctx, err))
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(ctx) {
+		idle.Stop()
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return fmt.Errorf("failed to decode document: %w", err)
+		}
+		if err := emit(doc); err != nil {
+			return err
+		}
+		idle.Reset(idleTimeout)
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to read documents: %w", streamError(ctx, err))
+	}
+	return nil
+}
+
+// streamIdleTimeout is how long a stream may wait for the next batch
+func streamIdleTimeout() time.Duration {
+	return models.DefaultContextConfig.MediumTimeout
+}
+
+// streamError reports the idle timeout instead of the bare cancellation it
+// causes in the driver
+func streamError(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil && cause != ctx.Err() {
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/configs"
+)
+
+// ErrEncryptionNotConfigured is returned when AES_KEY is missing
+var ErrEncryptionNotConfigured = errors.New("AES_KEY must be configured to use encrypted connection strings")
+
+// ErrInvalidEncryptedValue is returned when a ciphertext cannot be decrypted.
+// It never carries the cause, so callers cannot tell malformed input from a
+// failed authentication.
+var ErrInvalidEncryptedValue = errors.New("invalid encrypted value")
+
+// EncryptString encrypts plaintext with AES-GCM using the configured AES_KEY
+// and a random nonce, and returns base64(nonce || ciphertext || tag). The main
+// server uses the same scheme to send mongo_uri_enc values.
+func EncryptString(plaintext string) (string, error) {
+	aead, err := configuredAEAD()
+	if err != nil {
+		return "", err
+	}
+	return encryptWith(aead, plaintext)
+}
+
+// DecryptString reverses EncryptString
+func DecryptString(encoded string) (string, error) {
+	aead, err := configuredAEAD()
+	if err != nil {
+		return "", err
+	}
+	return decryptWith(aead, encoded)
+}
+
+func encryptWith(aead cipher.AEAD, plaintext string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptWith(aead cipher.AEAD, encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return "", ErrInvalidEncryptedValue
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrInvalidEncryptedValue
+	}
+
+	return string(plaintext), nil
+}
+
+// configuredAEAD builds the AES-GCM cipher from configuration
+func configuredAEAD() (cipher.AEAD, error) {
+	if configs.Env == nil || configs.Env.AES_key == "" {
+		return nil, ErrEncryptionNotConfigured
+	}
+	return newAEAD(decodeKeyMaterial(configs.Env.AES_key))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, fmt.Errorf("AES_KEY must be 16, 24 or 32 bytes (raw or hex encoded)")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decodeKeyMaterial accepts hex encoded values and falls back to raw bytes
+func decodeKeyMaterial(value string) []byte {
+	if decoded, err := hex.DecodeString(value); err == nil && len(decoded) > 0 {
+		return decoded
+	}
+	return []byte(value)
+}
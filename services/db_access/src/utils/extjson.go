@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrInvalidExtJSON is returned for request documents that are not valid
+// MongoDB Extended JSON
+var ErrInvalidExtJSON = errors.New("invalid extended JSON")
+
+// ExtJSONMode selects how documents are represented in request and response
+// bodies. Off keeps plain JSON, where dates and ObjectIDs are strings.
+type ExtJSONMode int
+
+const (
+	ExtJSONOff ExtJSONMode = iota
+	ExtJSONRelaxed
+	ExtJSONCanonical
+)
+
+// ParseExtJSONMode reads an Extended JSON flag: "relaxed" (or "true"),
+// "canonical", or "" / "false" for plain JSON
+func ParseExtJSONMode(value string) (ExtJSONMode, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "false", "0":
+		return ExtJSONOff, nil
+	case "relaxed", "true", "1":
+		return ExtJSONRelaxed, nil
+	case "canonical":
+		return ExtJSONCanonical, nil
+	}
+	return ExtJSONOff, fmt.Errorf("extended JSON mode must be relaxed or canonical, got %q", value)
+}
+
+// Enabled reports whether documents use Extended JSON
+func (m ExtJSONMode) Enabled() bool {
+	return m != ExtJSONOff
+}
+
+// EncodeValue converts a BSON value into plain JSON values that spell out its
+// Extended JSON form, so encoding/json writes {"$oid": ...} and friends.
+// Numbers stay json.Number to keep 64-bit integers exact.
+func (m ExtJSONMode) EncodeValue(value interface{}) (interface{}, error) {
+	if !m.Enabled() || value == nil {
+		return value, nil
+	}
+
+	// MarshalExtJSON only accepts documents, so the value is wrapped
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: value}}, m == ExtJSONCanonical, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode extended JSON: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var wrapped map[string]interface{}
+	if err := decoder.Decode(&wrapped); err != nil {
+		return nil, fmt.Errorf("failed to encode extended JSON: %w", err)
+	}
+	return wrapped["v"], nil
+}
+
+// EncodeDocument converts one document with EncodeValue
+func (m ExtJSONMode) EncodeDocument(doc bson.M) (bson.M, error) {
+	encoded, err := m.EncodeValue(doc)
+	if err != nil || !m.Enabled() || doc == nil {
+		return doc, err
+	}
+	return bson.M(encoded.(map[string]interface{})), nil
+}
+
+// EncodeDocuments converts documents in place
+func (m ExtJSONMode) EncodeDocuments(docs []bson.M) error {
+	for i, doc := range docs {
+		encoded, err := m.EncodeDocument(doc)
+		if err != nil {
+			return err
+		}
+		docs[i] = encoded
+	}
+	return nil
+}
+
+// DecodeExtJSONDocument decodes a document written in relaxed or canonical
+// Extended JSON, giving $oid, $date, $numberDecimal and $binary values their
+// BSON types
+func DecodeExtJSONDocument(raw json.RawMessage) (map[string]interface{}, error) {
+	var doc bson.M
+	if err := bson.UnmarshalExtJSON(raw, false, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExtJSON, err)
+	}
+	return doc, nil
+}
+
+// DecodeExtJSONDocuments decodes an array of Extended JSON documents
+func DecodeExtJSONDocuments(raw json.RawMessage) ([]map[string]interface{}, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("%w: expected an array of documents", ErrInvalidExtJSON)
+	}
+
+	docs := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		doc, err := DecodeExtJSONDocument(item)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseExtJSONMode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ExtJSONMode
+		wantErr bool
+	}{
+		{"", ExtJSONOff, false},
+		{"false", ExtJSONOff, false},
+		{"0", ExtJSONOff, false},
+		{"relaxed", ExtJSONRelaxed, false},
+		{"TRUE", ExtJSONRelaxed, false},
+		{" 1 ", ExtJSONRelaxed, false},
+		{"canonical", ExtJSONCanonical, false},
+		{"strict", ExtJSONOff, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseExtJSONMode(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseExtJSONMode(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestExtJSONRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	created := primitive.NewDateTimeFromTime(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC))
+	price, _ := primitive.ParseDecimal128("19.99")
+	blob := primitive.Binary{Subtype: 0, Data: []byte{1, 2, 3}}
+
+	tests := []struct {
+		name string
+		mode ExtJSONMode
+		doc  bson.M
+	}{
+		{
+			name: "relaxed",
+			mode: ExtJSONRelaxed,
+			doc:  bson.M{"_id": id, "created": created, "price": price, "blob": blob, "name": "Ada", "count": int32(4)},
+		},
+		{
+			name: "canonical",
+			mode: ExtJSONCanonical,
+			doc:  bson.M{"_id": id, "created": created, "price": price, "blob": blob, "big": int64(1) << 60, "ratio": 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs := []bson.M{tt.doc}
+			if err := tt.mode.EncodeDocuments(docs); err != nil {
+				t.Fatalf("EncodeDocuments returned error: %v", err)
+			}
+			body, err := json.Marshal(docs)
+			if err != nil {
+				t.Fatalf("json.Marshal returned error: %v", err)
+			}
+
+			decoded, err := DecodeExtJSONDocuments(body)
+			if err != nil {
+				t.Fatalf("DecodeExtJSONDocuments returned error: %v", err)
+			}
+			if len(decoded) != 1 || !reflect.DeepEqual(bson.M(decoded[0]), tt.doc) {
+				t.Errorf("round trip = %#v, want %#v", decoded, tt.doc)
+			}
+		})
+	}
+}
+
+func TestEncodeDocumentSpellsOutTypes(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("65f1c2a4b3d9e8f701234567")
+	doc := bson.M{"_id": id, "big": int64(1) << 60}
+
+	encoded, err := ExtJSONRelaxed.EncodeDocument(doc)
+	if err != nil {
+		t.Fatalf("EncodeDocument returned error: %v", err)
+	}
+	body, _ := json.Marshal(encoded)
+	if want := `{"_id":{"$oid":"65f1c2a4b3d9e8f701234567"},"big":1152921504606846976}`; string(body) != want {
+		t.Errorf("EncodeDocument = %s, want %s", body, want)
+	}
+
+	plain, err := ExtJSONOff.EncodeDocument(doc)
+	if err != nil || !reflect.DeepEqual(plain, doc) {
+		t.Errorf("EncodeDocument with Extended JSON off = %v, %v; want the document unchanged", plain, err)
+	}
+}
+
+func TestDecodeExtJSONRejects(t *testing.T) {
+	for _, raw := range []string{
+		`{"_id": {"$oid": "not-hex"}}`,
+		`{"created": {"$date": "yesterday"}}`,
+		`{"price": {"$numberDecimal": "abc"}}`,
+		`[1, 2]`,
+	} {
+		if _, err := DecodeExtJSONDocument(json.RawMessage(raw)); !errors.Is(err, ErrInvalidExtJSON) {
+			t.Errorf("DecodeExtJSONDocument(%s) error = %v, want ErrInvalidExtJSON", raw, err)
+		}
+	}
+
+	if _, err := DecodeExtJSONDocuments(json.RawMessage(`{"a": 1}`)); !errors.Is(err, ErrInvalidExtJSON) {
+		t.Errorf("DecodeExtJSONDocuments error = %v, want ErrInvalidExtJSON", err)
+	}
+	if _, err := DecodeExtJSONDocuments(json.RawMessage(`[{"a": 1}, {"_id": {"$oid": "zz"}}]`)); !errors.Is(err, ErrInvalidExtJSON) {
+		t.Errorf("DecodeExtJSONDocuments error = %v, want ErrInvalidExtJSON", err)
+	}
+}
@@ -0,0 +1,466 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidFilter is returned for filters that cannot be parsed or use an
+// operator outside the allowlist
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Filter limits keep a single request from building an expensive query
+const (
+	maxFilterLength     = 8192
+	maxFilterDepth      = 8
+	maxFilterConditions = 64
+	maxFilterListValues = 256
+	maxRegexLength      = 256
+)
+
+// FieldTypeFunc returns the detected type of a dotted field path (as reported
+// by AnalyzeSchema) or "" when unknown
+type FieldTypeFunc func(field string) string
+
+// comparisonOperators are the field level operators a filter may use
+var comparisonOperators = map[string]bool{
+	"$eq": true, "$ne": true, "$gt": true, "$gte": true, "$lt": true, "$lte": true,
+	"$in": true, "$nin": true, "$exists": true, "$regex": true, "$options": true,
+}
+
+// logicalOperators combine sub-filters at the top level
+var logicalOperators = map[string]bool{"$and": true, "$or": true, "$nor": true}
+
+// compactOperators maps the compact syntax to MongoDB operators
+var compactOperators = map[string]string{
+	"eq": "$eq", "ne": "$ne", "gt": "$gt", "gte": "$gte", "lt": "$lt", "lte": "$lte",
+	"in": "$in", "nin": "$nin", "exists": "$exists", "regex": "$regex",
+}
+
+// regexOptions are the regex flags accepted in $options
+var regexOptions = regexp.MustCompile(`^[imsx]*$`)
+
+// ParseFilter translates a user supplied filter into BSON. Two forms are
+// accepted:
+//
+//	JSON:    {"status": "pending", "total": {"$gt": 100}}
+//	Compact: status:pending,total:gt:100,tags:in:a|b,deleted_at:isnull:true
+//
+// Only allowlisted operators are accepted, so $where, $function, $expr and
+// friends are rejected. String values are coerced to the field's detected type
+// (ObjectID, date, number, boolean) using fieldType, which may be nil.
+func ParseFilter(raw string, fieldType FieldTypeFunc) (bson.M, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return bson.M{}, nil
+	}
+	if len(raw) > maxFilterLength {
+		return nil, fmt.Errorf("%w: filter exceeds %d characters", ErrInvalidFilter, maxFilterLength)
+	}
+	if fieldType == nil {
+		fieldType = func(string) string { return "" }
+	}
+
+	p := &filterParser{fieldType: fieldType}
+	if strings.HasPrefix(raw, "{") {
+		return p.parseJSON(raw)
+	}
+	return p.parseCompact(raw)
+}
+
+// filterParser carries state shared while translating one filter
+type filterParser struct {
+	fieldType  FieldTypeFunc
+	conditions int
+}
+
+// parseJSON decodes a JSON filter and validates it recursively
+func (p *filterParser) parseJSON(raw string) (bson.M, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.UseNumber()
+
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: malformed JSON: %v", ErrInvalidFilter, err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrInvalidFilter)
+	}
+
+	return p.translateDocument(doc, 0)
+}
+
+// translateDocument validates a filter document: field conditions and
+// top-level logical operators
+func (p *filterParser) translateDocument(doc map[string]interface{}, depth int) (bson.M, error) {
+	if depth > maxFilterDepth {
+		return nil, fmt.Errorf("%w: filter nested deeper than %d levels", ErrInvalidFilter, maxFilterDepth)
+	}
+
+	filter := bson.M{}
+	for key, value := range doc {
+		if strings.HasPrefix(key, "$") {
+			if !logicalOperators[key] {
+				return nil, fmt.Errorf("%w: operator %s is not allowed", ErrInvalidFilter, key)
+			}
+			clauses, err := p.translateLogical(key, value, depth)
+			if err != nil {
+				return nil, err
+			}
+			filter[key] = clauses
+			continue
+		}
+
+		if !IsValidFieldPath(key) {
+			return nil, fmt.Errorf("%w: invalid field name %q", ErrInvalidFilter, key)
+		}
+		condition, err := p.translateCondition(key, value)
+		if err != nil {
+			return nil, err
+		}
+		filter[key] = condition
+	}
+
+	return filter, nil
+}
+
+// translateLogical validates the array operand of $and, $or and $nor
+func (p *filterParser) translateLogical(operator string, value interface{}, depth int) (bson.A, error) {
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("%w: %s requires a non-empty array of filters", ErrInvalidFilter, operator)
+	}
+
+	clauses := make(bson.A, 0, len(items))
+	for _, item := range items {
+		doc, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s entries must be objects", ErrInvalidFilter, operator)
+		}
+		clause, err := p.translateDocument(doc, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses, nil
+}
+
+// translateCondition handles either an operator object or an implicit $eq value
+func (p *filterParser) translateCondition(field string, value interface{}) (interface{}, error) {
+	if err := p.countCondition(); err != nil {
+		return nil, err
+	}
+
+	operators, ok := value.(map[string]interface{})
+	if !ok || !hasOperatorKeys(operators) {
+		return p.translateValue(field, value)
+	}
+
+	condition := bson.M{}
+	for operator, operand := range operators {
+		if !strings.HasPrefix(operator, "$") {
+			return nil, fmt.Errorf("%w: cannot mix operators and field names in the condition for %q", ErrInvalidFilter, field)
+		}
+		translated, err := p.translateOperator(field, operator, operand)
+		if err != nil {
+			return nil, err
+		}
+		condition[operator] = translated
+	}
+
+	if _, hasOptions := condition["$options"]; hasOptions {
+		if _, hasRegex := condition["$regex"]; !hasRegex {
+			return nil, fmt.Errorf("%w: $options requires $regex on %q", ErrInvalidFilter, field)
+		}
+	}
+	return condition, nil
+}
+
+// translateOperator validates a single operator and coerces its operand
+func (p *filterParser) translateOperator(field, operator string, operand interface{}) (interface{}, error) {
+	if !comparisonOperators[operator] {
+		return nil, fmt.Errorf("%w: operator %s is not allowed", ErrInvalidFilter, operator)
+	}
+
+	switch operator {
+	case "$in", "$nin":
+		items, ok := operand.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s on %q requires an array", ErrInvalidFilter, operator, field)
+		}
+		if len(items) > maxFilterListValues {
+			return nil, fmt.Errorf("%w: %s on %q accepts at most %d values", ErrInvalidFilter, operator, field, maxFilterListValues)
+		}
+		values := make(bson.A, 0, len(items))
+		for _, item := range items {
+			value, err := p.translateValue(field, item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case "$exists":
+		switch v := operand.(type) {
+		case bool:
+			return v, nil
+		case string:
+			exists, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: $exists on %q requires true or false", ErrInvalidFilter, field)
+			}
+			return exists, nil
+		}
+		return nil, fmt.Errorf("%w: $exists on %q requires true or false", ErrInvalidFilter, field)
+	case "$regex":
+		pattern, ok := operand.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: $regex on %q requires a string", ErrInvalidFilter, field)
+		}
+		if len(pattern) > maxRegexLength {
+			return nil, fmt.Errorf("%w: $regex on %q exceeds %d characters", ErrInvalidFilter, field, maxRegexLength)
+		}
+		if err := checkRegex(pattern); err != nil {
+			return nil, fmt.Errorf("%w: $regex on %q: %v", ErrInvalidFilter, field, err)
+		}
+		return pattern, nil
+	case "$options":
+		flags, ok := operand.(string)
+		if !ok || !regexOptions.MatchString(flags) {
+			return nil, fmt.Errorf("%w: $options on %q may only contain i, m, s and x", ErrInvalidFilter, field)
+		}
+		return flags, nil
+	default:
+		return p.translateValue(field, operand)
+	}
+}
+
+// translateValue converts a JSON value into BSON, coercing strings to the
+// detected field type. Embedded documents may not contain operators.
+func (p *filterParser) translateValue(field string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, bool:
+		return v, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid number %s for %q", ErrInvalidFilter, v, field)
+		}
+		return f, nil
+	case string:
+		return coerceFilterValue(v, p.fieldType(field), field)
+	case []interface{}:
+		values := make(bson.A, 0, len(v))
+		for _, item := range v {
+			translated, err := p.translateValue(field, item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, translated)
+		}
+		return values, nil
+	case map[string]interface{}:
+		doc := bson.M{}
+		for key, item := range v {
+			if strings.HasPrefix(key, "$") {
+				return nil, fmt.Errorf("%w: operator %s is not allowed inside a value", ErrInvalidFilter, key)
+			}
+			translated, err := p.translateValue(field+"."+key, item)
+			if err != nil {
+				return nil, err
+			}
+			doc[key] = translated
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported value for %q", ErrInvalidFilter, field)
+	}
+}
+
+// parseCompact parses comma separated field:op:value conditions, which are
+// combined with AND. "field:value" is shorthand for "field:eq:value" and list
+// operators take "|" separated values.
+func (p *filterParser) parseCompact(raw string) (bson.M, error) {
+	filter := bson.M{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if err := p.countCondition(); err != nil {
+			return nil, err
+		}
+
+		pieces := strings.SplitN(part, ":", 3)
+		if len(pieces) < 2 {
+			return nil, fmt.Errorf("%w: expected field:op:value, got %q", ErrInvalidFilter, part)
+		}
+
+		field := strings.TrimSpace(pieces[0])
+		if !IsValidFieldPath(field) {
+			return nil, fmt.Errorf("%w: invalid field name %q", ErrInvalidFilter, field)
+		}
+
+		operator, isOperator := compactOperators[strings.ToLower(pieces[1])]
+		value := strings.Join(pieces[1:], ":")
+		var operand interface{} = value
+		if strings.EqualFold(pieces[1], "isnull") {
+			// field:isnull:true matches null or missing, false the opposite
+			if len(pieces) < 3 {
+				return nil, fmt.Errorf("%w: missing value in %q", ErrInvalidFilter, part)
+			}
+			isNull, err := strconv.ParseBool(pieces[2])
+			if err != nil {
+				return nil, fmt.Errorf("%w: isnull on %q requires true or false", ErrInvalidFilter, field)
+			}
+			operator, operand = "$eq", nil
+			if !isNull {
+				operator = "$ne"
+			}
+		} else if isOperator {
+			if len(pieces) < 3 {
+				return nil, fmt.Errorf("%w: missing value in %q", ErrInvalidFilter, part)
+			}
+			value = pieces[2]
+			operand = value
+		} else {
+			operator = "$eq"
+		}
+
+		if operator == "$in" || operator == "$nin" {
+			items := make([]interface{}, 0)
+			for _, item := range strings.Split(value, "|") {
+				items = append(items, item)
+			}
+			operand = items
+		}
+
+		translated, err := p.translateOperator(field, operator, operand)
+		if err != nil {
+			return nil, err
+		}
+
+		condition, ok := filter[field].(bson.M)
+		if !ok {
+			condition = bson.M{}
+			filter[field] = condition
+		}
+		if _, exists := condition[operator]; exists {
+			return nil, fmt.Errorf("%w: %s given twice for %q", ErrInvalidFilter, operator, field)
+		}
+		condition[operator] = translated
+	}
+
+	return filter, nil
+}
+
+// checkRegex rejects patterns that are likely to backtrack catastrophically.
+// MongoDB evaluates $regex with PCRE, so compiling with RE2 only proves the
+// syntax is sane; quantified groups that themselves contain a quantifier, such
+// as (a+)+ or (\w*\s?)*, are what make PCRE exponential and are refused.
+// Reads that apply filters also run with maxTimeMS as a backstop.
+func checkRegex(pattern string) error {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return err
+	}
+	if hasNestedQuantifier(re, false) {
+		return errors.New("nested quantifiers are not allowed")
+	}
+	return nil
+}
+
+// hasNestedQuantifier reports whether an unbounded or multi-match repetition
+// appears inside another repetition
+func hasNestedQuantifier(re *syntax.Regexp, inRepeat bool) bool {
+	repeats := false
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		repeats = true
+	case syntax.OpRepeat:
+		repeats = re.Max == -1 || re.Max > 1
+	}
+	if repeats && inRepeat {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasNestedQuantifier(sub, inRepeat || repeats) {
+			return true
+		}
+	}
+	return false
+}
+
+// countCondition enforces maxFilterConditions
+func (p *filterParser) countCondition() error {
+	p.conditions++
+	if p.conditions > maxFilterConditions {
+		return fmt.Errorf("%w: filter has more than %d conditions", ErrInvalidFilter, maxFilterConditions)
+	}
+	return nil
+}
+
+// hasOperatorKeys reports whether any key of doc is an operator
+func hasOperatorKeys(doc map[string]interface{}) bool {
+	for key := range doc {
+		if strings.HasPrefix(key, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// filterDateLayouts are accepted for date fields, most specific first
+var filterDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+// coerceFilterValue converts a string to the field's detected type. Unknown
+// and string fields keep the string, so "null" is the string "null"; null is
+// written as JSON null or with the compact isnull operator.
+func coerceFilterValue(value, fieldType, field string) (interface{}, error) {
+	switch fieldType {
+	case "ObjectID":
+		objectID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid ObjectID for %q", ErrInvalidFilter, value, field)
+		}
+		return objectID, nil
+	case "date":
+		for _, layout := range filterDateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return primitive.NewDateTimeFromTime(t), nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %q is not a valid date for %q (use RFC 3339 or YYYY-MM-DD)", ErrInvalidFilter, value, field)
+	case "number":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid number for %q", ErrInvalidFilter, value, field)
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid boolean for %q", ErrInvalidFilter, value, field)
+		}
+		return b, nil
+	default:
+		return value, nil
+	}
+}
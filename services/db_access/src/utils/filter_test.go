@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseFilterAccepts(t *testing.T) {
+	objectID, _ := primitive.ObjectIDFromHex("5f1b2c3d4e5f6a7b8c9d0e1f")
+	fieldTypes := map[string]string{"owner": "ObjectID", "total": "number", "active": "boolean"}
+	lookup := func(field string) string { return fieldTypes[field] }
+
+	tests := []struct {
+		name string
+		raw  string
+		want bson.M
+	}{
+		{"empty", "", bson.M{}},
+		{"json equality", `{"status": "pending"}`, bson.M{"status": "pending"}},
+		{"json operators", `{"total": {"$gt": 100, "$lte": 2.5}}`, bson.M{"total": bson.M{"$gt": int64(100), "$lte": 2.5}}},
+		{"json null", `{"deleted_at": null}`, bson.M{"deleted_at": nil}},
+		{"json logical", `{"$or": [{"a": 1}, {"b": "x"}]}`, bson.M{"$or": bson.A{bson.M{"a": int64(1)}, bson.M{"b": "x"}}}},
+		{"json in", `{"tags": {"$in": ["a", "b"]}}`, bson.M{"tags": bson.M{"$in": bson.A{"a", "b"}}}},
+		{"json regex", `{"name": {"$regex": "^ab", "$options": "i"}}`, bson.M{"name": bson.M{"$regex": "^ab", "$options": "i"}}},
+		{"coerced ObjectID", `{"owner": "5f1b2c3d4e5f6a7b8c9d0e1f"}`, bson.M{"owner": objectID}},
+		{"compact equality", "status:pending", bson.M{"status": bson.M{"$eq": "pending"}}},
+		{"compact coerced", "total:gt:100,active:true", bson.M{"total": bson.M{"$gt": int64(100)}, "active": bson.M{"$eq": true}}},
+		{"compact list", "tags:in:a|b", bson.M{"tags": bson.M{"$in": bson.A{"a", "b"}}}},
+		{"compact value with colon", "time:12:30", bson.M{"time": bson.M{"$eq": "12:30"}}},
+		{"string null stays a string", "status:null", bson.M{"status": bson.M{"$eq": "null"}}},
+		{"compact isnull", "deleted_at:isnull:true", bson.M{"deleted_at": bson.M{"$eq": nil}}},
+		{"compact not null", "deleted_at:isnull:false", bson.M{"deleted_at": bson.M{"$ne": nil}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.raw, lookup)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q) returned error: %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilter(%q) = %#v, want %#v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterRejects(t *testing.T) {
+	fieldTypes := map[string]string{"owner": "ObjectID", "created": "date"}
+	lookup := func(field string) string { return fieldTypes[field] }
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"$where", `{"$where": "sleep(1000)"}`},
+		{"$expr", `{"$expr": {"$gt": ["$a", "$b"]}}`},
+		{"$function in condition", `{"a": {"$function": {"body": "x"}}}`},
+		{"operator inside value", `{"a": {"b": {"$where": "1"}}}`},
+		{"mixed operators and fields", `{"a": {"$gt": 1, "b": 2}}`},
+		{"empty $or", `{"$or": []}`},
+		{"non-object $and entry", `{"$and": [1]}`},
+		{"invalid field name", `{"a..b": 1}`},
+		{"dollar field in compact", "$where:1"},
+		{"malformed JSON", `{"a": `},
+		{"trailing data", `{"a": 1} {"b": 2}`},
+		{"$options without $regex", `{"a": {"$options": "i"}}`},
+		{"bad regex options", `{"a": {"$regex": "x", "$options": "g"}}`},
+		{"invalid regex", `{"a": {"$regex": "("}}`},
+		{"nested quantifier", `{"a": {"$regex": "(a+)+$"}}`},
+		{"nested star", "a:regex:(\\w*\\s?)*$"},
+		{"nested counted repeat", `{"a": {"$regex": "(x{2,})*"}}`},
+		{"regex too long", `{"a": {"$regex": "` + strings.Repeat("a", maxRegexLength+1) + `"}}`},
+		{"$in without array", `{"a": {"$in": "x"}}`},
+		{"$exists not boolean", "a:exists:maybe"},
+		{"isnull not boolean", "a:isnull:maybe"},
+		{"missing compact value", "a:gt"},
+		{"duplicate compact operator", "a:gt:1,a:gt:2"},
+		{"bad ObjectID", `{"owner": "nope"}`},
+		{"bad date", "created:gt:yesterday"},
+		{"too long", strings.Repeat("a:1,", maxFilterLength)},
+		{"too many conditions", strings.Repeat("a:1,", maxFilterConditions+1)},
+		{"too deep", strings.Repeat(`{"$and": [`, maxFilterDepth+2) + `{"a": 1}` + strings.Repeat(`]}`, maxFilterDepth+2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.raw, lookup)
+			if !errors.Is(err, ErrInvalidFilter) {
+				t.Fatalf("ParseFilter(%q) = %#v, %v; want ErrInvalidFilter", tt.raw, got, err)
+			}
+		})
+	}
+}
+
+func TestCheckRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{`^abc`, true},
+		{`[a-z]+@example\.com$`, true},
+		{`x*y*z+`, true},
+		{`(ab){2,3}`, true},
+		{`(ab)?c+`, true},
+		{`(a+)+$`, false},
+		{`(a*)*`, false},
+		{`(\d+\.)+\d`, false},
+		{`((ab)*c)+`, false},
+		{`(a{2,})*`, false},
+	}
+
+	for _, tt := range tests {
+		err := checkRegex(tt.pattern)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkRegex(%q) = %v, want ok=%v", tt.pattern, err, tt.ok)
+		}
+	}
+}
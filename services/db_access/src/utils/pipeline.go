@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidPipeline is returned for aggregation pipelines that cannot be
+// parsed or use a stage or operator outside the allowlist
+var ErrInvalidPipeline = errors.New("invalid pipeline")
+
+// Pipeline limits
+const (
+	maxPipelineStages = 50
+	maxPipelineDepth  = 4 // nesting through $facet and $lookup sub-pipelines
+)
+
+// allowedStages are the aggregation stages a dashboard pipeline may use.
+// Anything that writes ($out, $merge) or reaches outside the collection's
+// database is deliberately missing.
+var allowedStages = map[string]bool{
+	"$match":   true,
+	"$group":   true,
+	"$project": true,
+	"$sort":    true,
+	"$limit":   true,
+	"$unwind":  true,
+	"$bucket":  true,
+	"$facet":   true,
+	"$lookup":  true,
+}
+
+// deniedOperators run server-side JavaScript and are rejected at any depth
+var deniedOperators = map[string]bool{
+	"$where":       true,
+	"$function":    true,
+	"$accumulator": true,
+}
+
+// ParsePipeline parses a JSON (Extended JSON allowed) array of stages and
+// validates it against the stage allowlist
+func ParsePipeline(raw []byte) (bson.A, error) {
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil, fmt.Errorf("%w: pipeline is required", ErrInvalidPipeline)
+	}
+
+	// Extended JSON parsing needs a document at the top level
+	wrapped := append(append([]byte(`{"pipeline":`), raw...), '}')
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON(wrapped, false, &doc); err != nil {
+		return nil, fmt.Errorf("%w: malformed JSON: %v", ErrInvalidPipeline, err)
+	}
+	if len(doc) != 1 {
+		return nil, fmt.Errorf("%w: pipeline must be an array of stages", ErrInvalidPipeline)
+	}
+	stages, ok := doc[0].Value.(primitive.A)
+	if !ok {
+		return nil, fmt.Errorf("%w: pipeline must be an array of stages", ErrInvalidPipeline)
+	}
+
+	if err := validatePipeline(stages, "", 0); err != nil {
+		return nil, err
+	}
+	return bson.A(stages), nil
+}
+
+// validatePipeline checks every stage; location prefixes errors for nested
+// pipelines, e.g. `stage 2 ($facet) facet "byStatus" `
+func validatePipeline(stages primitive.A, location string, depth int) error {
+	if depth > maxPipelineDepth {
+		return fmt.Errorf("%w: %spipelines nested deeper than %d levels", ErrInvalidPipeline, location, maxPipelineDepth)
+	}
+	if len(stages) > maxPipelineStages {
+		return fmt.Errorf("%w: %spipeline has more than %d stages", ErrInvalidPipeline, location, maxPipelineStages)
+	}
+
+	for i, item := range stages {
+		stage, ok := item.(primitive.D)
+		if !ok || len(stage) != 1 {
+			return fmt.Errorf("%w: %sstage %d must be an object with exactly one stage operator", ErrInvalidPipeline, location, i+1)
+		}
+
+		name, spec := stage[0].Key, stage[0].Value
+		where := fmt.Sprintf("%sstage %d (%s)", location, i+1, name)
+		if !allowedStages[name] {
+			return fmt.Errorf("%w: %s is not allowed", ErrInvalidPipeline, where)
+		}
+		if operator := findDeniedOperator(spec); operator != "" {
+			return fmt.Errorf("%w: %s uses %s, which is not allowed", ErrInvalidPipeline, where, operator)
+		}
+
+		var err error
+		switch name {
+		case "$limit":
+			err = validatePositiveInt(spec)
+		case "$facet":
+			err = validateFacet(spec, where, depth)
+		case "$lookup":
+			err = validateLookup(spec, where, depth)
+		}
+		if err != nil {
+			if errors.Is(err, ErrInvalidPipeline) {
+				return err
+			}
+			return fmt.Errorf("%w: %s: %v", ErrInvalidPipeline, where, err)
+		}
+	}
+
+	return nil
+}
+
+// validateFacet validates each named sub-pipeline of $facet
+func validateFacet(spec interface{}, where string, depth int) error {
+	facets, ok := spec.(primitive.D)
+	if !ok || len(facets) == 0 {
+		return errors.New("requires an object of named pipelines")
+	}
+
+	for _, facet := range facets {
+		pipeline, ok := facet.Value.(primitive.A)
+		if !ok {
+			return fmt.Errorf("facet %q must be an array of stages", facet.Key)
+		}
+		location := fmt.Sprintf("%s facet %q ", where, facet.Key)
+		if err := validatePipeline(pipeline, location, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLookup restricts $lookup to collections in the same database and
+// validates its sub-pipeline
+func validateLookup(spec interface{}, where string, depth int) error {
+	lookup, ok := spec.(primitive.D)
+	if !ok {
+		return errors.New("requires an object")
+	}
+
+	hasFrom := false
+	for _, field := range lookup {
+		switch field.Key {
+		case "from":
+			from, ok := field.Value.(string)
+			if !ok {
+				return errors.New("from must be a collection name in the same database")
+			}
+			if !IsValidCollectionName(from) {
+				return fmt.Errorf("invalid collection name %q in from", from)
+			}
+			hasFrom = true
+		case "pipeline":
+			pipeline, ok := field.Value.(primitive.A)
+			if !ok {
+				return errors.New("pipeline must be an array of stages")
+			}
+			if err := validatePipeline(pipeline, where+" pipeline ", depth+1); err != nil {
+				return err
+			}
+		case "localField", "foreignField", "as", "let":
+		default:
+			return fmt.Errorf("option %q is not allowed", field.Key)
+		}
+	}
+
+	if !hasFrom {
+		return errors.New("from is required")
+	}
+	return nil
+}
+
+// validatePositiveInt checks a $limit value
+func validatePositiveInt(value interface{}) error {
+	var n int64
+	switch v := value.(type) {
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case float64:
+		if v != float64(int64(v)) {
+			return errors.New("must be a positive integer")
+		}
+		n = int64(v)
+	default:
+		return errors.New("must be a positive integer")
+	}
+	if n < 1 {
+		return errors.New("must be a positive integer")
+	}
+	return nil
+}
+
+// findDeniedOperator returns the first denied operator found at any depth
+func findDeniedOperator(value interface{}) string {
+	switch v := value.(type) {
+	case primitive.D:
+		for _, field := range v {
+			if deniedOperators[field.Key] {
+				return field.Key
+			}
+			if operator := findDeniedOperator(field.Value); operator != "" {
+				return operator
+			}
+		}
+	case primitive.A:
+		for _, item := range v {
+			if operator := findDeniedOperator(item); operator != "" {
+				return operator
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePipelineAccepts(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"match and group", `[{"$match": {"status": "paid"}}, {"$group": {"_id": "$customer", "total": {"$sum": "$amount"}}}]`},
+		{"sort, limit, project", `[{"$sort": {"total": -1}}, {"$limit": 10}, {"$project": {"name": 1}}]`},
+		{"extended JSON", `[{"$match": {"_id": {"$oid": "5f1b2c3d4e5f6a7b8c9d0e1f"}, "at": {"$gte": {"$date": "2024-01-01T00:00:00Z"}}}}]`},
+		{"unwind and bucket", `[{"$unwind": "$items"}, {"$bucket": {"groupBy": "$price", "boundaries": [0, 10, 100]}}]`},
+		{"facet", `[{"$facet": {"byStatus": [{"$group": {"_id": "$status"}}], "top": [{"$limit": 5}]}}]`},
+		{"lookup", `[{"$lookup": {"from": "customers", "localField": "customer", "foreignField": "_id", "as": "customer"}}]`},
+		{"lookup pipeline", `[{"$lookup": {"from": "items", "let": {"id": "$_id"}, "pipeline": [{"$match": {"sku": "x"}}], "as": "items"}}]`},
+		{"empty", `[]`},
+		{"nested at limit", nestedFacets(maxPipelineDepth)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePipeline([]byte(tt.raw)); err != nil {
+				t.Errorf("ParsePipeline(%s) returned error: %v", tt.raw, err)
+			}
+		})
+	}
+}
+
+func TestParsePipelineRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"blank", "  "},
+		{"malformed", `[{"$match": `},
+		{"not an array", `{"$match": {}}`},
+		{"stage not an object", `["$match"]`},
+		{"two operators in a stage", `[{"$match": {}, "$limit": 1}]`},
+		{"$out", `[{"$match": {}}, {"$out": "stolen"}]`},
+		{"$merge", `[{"$merge": {"into": "stolen"}}]`},
+		{"$unionWith", `[{"$unionWith": "users"}]`},
+		{"$currentOp", `[{"$currentOp": {}}]`},
+		{"unknown stage", `[{"$addFields": {"x": 1}}]`},
+		{"$where in match", `[{"$match": {"$where": "sleep(100)"}}]`},
+		{"$function in project", `[{"$project": {"x": {"$function": {"body": "function() {}", "args": [], "lang": "js"}}}}]`},
+		{"$accumulator in group", `[{"$group": {"_id": null, "x": {"$accumulator": {}}}}]`},
+		{"$where inside array", `[{"$match": {"$or": [{"a": 1}, {"$where": "1"}]}}]`},
+		{"$where inside facet", `[{"$facet": {"a": [{"$match": {"$where": "1"}}]}}]`},
+		{"$out inside facet", `[{"$facet": {"a": [{"$out": "x"}]}}]`},
+		{"$merge inside lookup", `[{"$lookup": {"from": "a", "pipeline": [{"$merge": "b"}], "as": "x"}}]`},
+		{"lookup into another database", `[{"$lookup": {"from": {"db": "admin", "coll": "system.users"}, "as": "x"}}]`},
+		{"lookup system collection", `[{"$lookup": {"from": "system.profile", "as": "x"}}]`},
+		{"lookup without from", `[{"$lookup": {"localField": "a", "as": "x"}}]`},
+		{"lookup unknown option", `[{"$lookup": {"from": "a", "as": "x", "coll": "b"}}]`},
+		{"empty facet", `[{"$facet": {}}]`},
+		{"facet not an array", `[{"$facet": {"a": {"$match": {}}}}]`},
+		{"zero limit", `[{"$limit": 0}]`},
+		{"negative limit", `[{"$limit": -5}]`},
+		{"fractional limit", `[{"$limit": 2.5}]`},
+		{"string limit", `[{"$limit": "10"}]`},
+		{"too many stages", "[" + strings.TrimSuffix(strings.Repeat(`{"$limit": 1},`, maxPipelineStages+1), ",") + "]"},
+		{"too deep", nestedFacets(maxPipelineDepth + 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePipeline([]byte(tt.raw)); !errors.Is(err, ErrInvalidPipeline) {
+				t.Errorf("ParsePipeline(%s) error = %v, want ErrInvalidPipeline", tt.raw, err)
+			}
+		})
+	}
+}
+
+// nestedFacets builds a pipeline whose innermost stage sits depth $facet
+// levels down
+func nestedFacets(depth int) string {
+	pipeline := `[{"$limit": 1}]`
+	for i := 0; i < depth; i++ {
+		pipeline = `[{"$facet": {"f": ` + pipeline + `}}]`
+	}
+	return pipeline
+}
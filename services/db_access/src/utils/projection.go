@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrInvalidSort is returned for malformed sort specifications
+var ErrInvalidSort = errors.New("invalid sort")
+
+// ErrInvalidProjection is returned for malformed field projections
+var ErrInvalidProjection = errors.New("invalid fields")
+
+// Limits on sort keys and projected paths per request
+const (
+	maxSortKeys         = 8
+	maxProjectionFields = 64
+)
+
+// ParseSort parses a comma separated sort specification such as
+// "-total,name" or "total:desc,name:asc" into an ordered BSON sort document
+func ParseSort(raw string) (bson.D, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	sort := bson.D{}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field, direction := part, 1
+		switch {
+		case strings.HasPrefix(part, "-"):
+			field, direction = part[1:], -1
+		case strings.HasPrefix(part, "+"):
+			field = part[1:]
+		case strings.Contains(part, ":"):
+			pieces := strings.SplitN(part, ":", 2)
+			field = pieces[0]
+			switch strings.ToLower(pieces[1]) {
+			case "asc", "1":
+				direction = 1
+			case "desc", "-1":
+				direction = -1
+			default:
+				return nil, fmt.Errorf("%w: direction for %q must be asc or desc", ErrInvalidSort, field)
+			}
+		}
+
+		if !IsValidFieldPath(field) {
+			return nil, fmt.Errorf("%w: invalid field name %q", ErrInvalidSort, field)
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("%w: %q listed twice", ErrInvalidSort, field)
+		}
+		seen[field] = true
+
+		sort = append(sort, bson.E{Key: field, Value: direction})
+		if len(sort) > maxSortKeys {
+			return nil, fmt.Errorf("%w: at most %d sort keys are allowed", ErrInvalidSort, maxSortKeys)
+		}
+	}
+
+	return sort, nil
+}
+
+// ParseProjection parses a comma separated field list. Plain paths are
+// included ("name,address.city"); paths prefixed with "-" are excluded
+// ("-payload,-history"). Inclusion and exclusion cannot be mixed, except that
+// "-_id" may accompany an inclusion list.
+func ParseProjection(raw string) (bson.M, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	projection := bson.M{}
+	var paths []string
+	includes, excludes := 0, 0
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field, value := part, 1
+		if strings.HasPrefix(part, "-") {
+			field, value = part[1:], 0
+		}
+		if !IsValidFieldPath(field) {
+			return nil, fmt.Errorf("%w: invalid field name %q", ErrInvalidProjection, field)
+		}
+		if _, exists := projection[field]; exists {
+			return nil, fmt.Errorf("%w: %q listed twice", ErrInvalidProjection, field)
+		}
+
+		// MongoDB rejects a path together with one of its sub-paths
+		for _, other := range paths {
+			if strings.HasPrefix(field, other+".") || strings.HasPrefix(other, field+".") {
+				return nil, fmt.Errorf("%w: %q and %q overlap", ErrInvalidProjection, other, field)
+			}
+		}
+		paths = append(paths, field)
+
+		if value == 1 {
+			includes++
+		} else if field != "_id" {
+			excludes++
+		}
+		projection[field] = value
+
+		if len(projection) > maxProjectionFields {
+			return nil, fmt.Errorf("%w: at most %d fields are allowed", ErrInvalidProjection, maxProjectionFields)
+		}
+	}
+
+	if includes > 0 && excludes > 0 {
+		return nil, fmt.Errorf("%w: cannot mix included and excluded fields (except -_id)", ErrInvalidProjection)
+	}
+	return projection, nil
+}
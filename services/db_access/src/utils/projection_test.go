@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want bson.D
+	}{
+		{"empty", "", nil},
+		{"prefix directions", "-total,+name,age", bson.D{{Key: "total", Value: -1}, {Key: "name", Value: 1}, {Key: "age", Value: 1}}},
+		{"suffix directions", "total:desc,name:ASC,age:-1", bson.D{{Key: "total", Value: -1}, {Key: "name", Value: 1}, {Key: "age", Value: -1}}},
+		{"nested path", "address.city", bson.D{{Key: "address.city", Value: 1}}},
+		{"blank entries", " name , ,", bson.D{{Key: "name", Value: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSort(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseSort(%q) returned error: %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"operator field", "$where"},
+		{"operator in path", "a.$b"},
+		{"bad direction", "name:sideways"},
+		{"duplicate", "name,-name"},
+		{"empty path segment", "a..b"},
+		{"too many keys", fieldList(maxSortKeys + 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSort(tt.raw); !errors.Is(err, ErrInvalidSort) {
+				t.Errorf("ParseSort(%q) error = %v, want ErrInvalidSort", tt.raw, err)
+			}
+		})
+	}
+}
+
+func TestParseProjection(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want bson.M
+	}{
+		{"empty", "", nil},
+		{"include", "name,address.city", bson.M{"name": 1, "address.city": 1}},
+		{"exclude", "-payload,-history", bson.M{"payload": 0, "history": 0}},
+		{"include without _id", "name,-_id", bson.M{"name": 1, "_id": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProjection(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseProjection(%q) returned error: %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseProjection(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProjectionRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"operator field", "$where"},
+		{"mixed", "name,-payload"},
+		{"duplicate", "name,name"},
+		{"overlapping paths", "address,address.city"},
+		{"overlapping paths reversed", "address.city,address"},
+		{"too many fields", fieldList(maxProjectionFields + 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseProjection(tt.raw); !errors.Is(err, ErrInvalidProjection) {
+				t.Errorf("ParseProjection(%q) error = %v, want ErrInvalidProjection", tt.raw, err)
+			}
+		})
+	}
+}
+
+// fieldList returns n distinct comma separated field names
+func fieldList(n int) string {
+	fields := make([]string, n)
+	for i := range fields {
+		fields[i] = fmt.Sprintf("f%d", i)
+	}
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/abhidhanve/universal-dashboard/services/db_access/configs"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed or whose
+// signature does not match
+var ErrInvalidToken = errors.New("invalid or tampered token")
+
+var (
+	ephemeralKeyOnce sync.Once
+	ephemeralKey     []byte
+)
+
+// SignPayload serializes v as JSON and returns "<payload>.<signature>", both
+// base64url encoded. The payload is readable, so it must not hold secrets.
+func SignPayload(v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token: %v", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(encoded))), nil
+}
+
+// VerifyPayload checks the signature of a token from SignPayload and decodes
+// its payload into v
+func VerifyPayload(token string, v interface{}) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidToken
+	}
+
+	expected, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, sign([]byte(encoded))) {
+		return ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// sign computes the HMAC-SHA256 of data with the signing key
+func sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, signingKey())
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// signingKey returns TOKEN_SECRET, or a random per-process key when unset.
+// With the random key, tokens stop verifying after a restart and are not
+// portable across replicas.
+func signingKey() []byte {
+	if configs.Env != nil && configs.Env.TokenSecret != "" {
+		return []byte(configs.Env.TokenSecret)
+	}
+
+	ephemeralKeyOnce.Do(func() {
+		ephemeralKey = make([]byte, 32)
+		if _, err := rand.Read(ephemeralKey); err != nil {
+			panic(fmt.Sprintf("failed to generate token signing key: %v", err))
+		}
+	})
+	return ephemeralKey
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testToken struct {
+	Namespace string `json:"ns"`
+	Count     int    `json:"n"`
+}
+
+func TestSignPayloadRoundTrip(t *testing.T) {
+	token, err := SignPayload(testToken{Namespace: "shop.orders", Count: 3})
+	if err != nil {
+		t.Fatalf("SignPayload returned error: %v", err)
+	}
+
+	var decoded testToken
+	if err := VerifyPayload(token, &decoded); err != nil {
+		t.Fatalf("VerifyPayload returned error: %v", err)
+	}
+	if decoded.Namespace != "shop.orders" || decoded.Count != 3 {
+		t.Errorf("VerifyPayload decoded %+v", decoded)
+	}
+}
+
+func TestVerifyPayloadRejects(t *testing.T) {
+	token, err := SignPayload(testToken{Namespace: "shop.orders", Count: 3})
+	if err != nil {
+		t.Fatalf("SignPayload returned error: %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"ns":"admin.users","n":3}`))
+	other, err := SignPayload(testToken{Namespace: "shop.users"})
+	if err != nil {
+		t.Fatalf("SignPayload returned error: %v", err)
+	}
+	_, otherSignature, _ := strings.Cut(other, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"missing signature", payload},
+		{"empty signature", payload + "."},
+		{"forged payload", forged + "." + signature},
+		{"swapped signature", payload + "." + otherSignature},
+		{"truncated signature", payload + "." + signature[:len(signature)-2]},
+		{"signature not base64", payload + ".!!!"},
+		{"extra segment", token + ".x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded testToken
+			if err := VerifyPayload(tt.token, &decoded); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("VerifyPayload(%q) error = %v, want ErrInvalidToken", tt.token, err)
+			}
+		})
+	}
+}
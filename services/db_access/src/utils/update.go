@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidUpdate is returned for update documents that cannot be parsed or
+// use an operator outside the allowlist
+var ErrInvalidUpdate = errors.New("invalid update")
+
+// Limit on fields changed by one update document
+const maxUpdateFields = 100
+
+// Modifiers accepted inside $push and $addToSet values
+var arrayUpdateModifiers = map[string]map[string]bool{
+	"$push":     {"$each": true, "$position": true, "$slice": true, "$sort": true},
+	"$addToSet": {"$each": true},
+}
+
+// ParseUpdate parses a JSON (Extended JSON allowed) update document such as
+// {"$set": {"status": "done"}, "$inc": {"retries": 1}}. Only operators in
+// allowed may be used, and _id can never be changed.
+func ParseUpdate(raw []byte, allowed map[string]bool) (bson.D, error) {
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil, fmt.Errorf("%w: update is required", ErrInvalidUpdate)
+	}
+
+	var update bson.D
+	if err := bson.UnmarshalExtJSON(raw, false, &update); err != nil {
+		return nil, fmt.Errorf("%w: update must be a JSON object: %v", ErrInvalidUpdate, err)
+	}
+	if len(update) == 0 {
+		return nil, fmt.Errorf("%w: update is empty", ErrInvalidUpdate)
+	}
+
+	var paths []string
+	for _, op := range update {
+		if !strings.HasPrefix(op.Key, "$") {
+			return nil, fmt.Errorf("%w: %q is not an update operator, wrap fields in $set", ErrInvalidUpdate, op.Key)
+		}
+		if !allowed[op.Key] {
+			return nil, fmt.Errorf("%w: operator %s is not allowed", ErrInvalidUpdate, op.Key)
+		}
+
+		changes, ok := op.Value.(primitive.D)
+		if !ok || len(changes) == 0 {
+			return nil, fmt.Errorf("%w: %s needs an object of fields", ErrInvalidUpdate, op.Key)
+		}
+		for _, change := range changes {
+			if !IsValidFieldPath(change.Key) {
+				return nil, fmt.Errorf("%w: invalid field name %q in %s", ErrInvalidUpdate, change.Key, op.Key)
+			}
+			if change.Key == "_id" || strings.HasPrefix(change.Key, "_id.") {
+				return nil, fmt.Errorf("%w: _id cannot be changed", ErrInvalidUpdate)
+			}
+			if err := validateUpdateValue(op.Key, change.Key, change.Value); err != nil {
+				return nil, err
+			}
+
+			// MongoDB rejects updates touching a path and one of its sub-paths
+			for _, other := range paths {
+				if other == change.Key || strings.HasPrefix(change.Key, other+".") || strings.HasPrefix(other, change.Key+".") {
+					return nil, fmt.Errorf("%w: %q and %q conflict", ErrInvalidUpdate, other, change.Key)
+				}
+			}
+			paths = append(paths, change.Key)
+		}
+
+		if len(paths) > maxUpdateFields {
+			return nil, fmt.Errorf("%w: at most %d fields can be changed at once", ErrInvalidUpdate, maxUpdateFields)
+		}
+	}
+
+	return update, nil
+}
+
+// validateUpdateValue checks the value an operator applies to one field
+func validateUpdateValue(operator, field string, value interface{}) error {
+	switch operator {
+	case "$inc":
+		if !isNumber(value) {
+			return fmt.Errorf("%w: $inc needs a number for %q", ErrInvalidUpdate, field)
+		}
+	case "$currentDate":
+		if value == true {
+			return nil
+		}
+		spec, ok := value.(primitive.D)
+		if !ok || len(spec) != 1 || spec[0].Key != "$type" || (spec[0].Value != "date" && spec[0].Value != "timestamp") {
+			return fmt.Errorf(`%w: $currentDate needs true or {"$type": "date"} for %q`, ErrInvalidUpdate, field)
+		}
+	case "$push", "$addToSet":
+		// A value whose keys are modifiers, e.g. {"$each": [...]}
+		spec, ok := value.(primitive.D)
+		if !ok || len(spec) == 0 || !strings.HasPrefix(spec[0].Key, "$") {
+			return nil
+		}
+		for _, modifier := range spec {
+			if !arrayUpdateModifiers[operator][modifier.Key] {
+				return fmt.Errorf("%w: modifier %s is not allowed in %s", ErrInvalidUpdate, modifier.Key, operator)
+			}
+		}
+	}
+	return nil
+}
+
+// isNumber reports whether a decoded BSON value is numeric
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int32, int64, float64, primitive.Decimal128:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testUpdateOperators = map[string]bool{
+	"$set":         true,
+	"$unset":       true,
+	"$inc":         true,
+	"$push":        true,
+	"$addToSet":    true,
+	"$currentDate": true,
+}
+
+func TestParseUpdateAccepts(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"set", `{"$set": {"status": "done", "address.city": "Oslo"}}`},
+		{"several operators", `{"$set": {"status": "done"}, "$inc": {"retries": 1}, "$unset": {"error": ""}}`},
+		{"inc float", `{"$inc": {"balance": -2.5}}`},
+		{"inc decimal", `{"$inc": {"balance": {"$numberDecimal": "1.10"}}}`},
+		{"currentDate true", `{"$currentDate": {"updated_at": true}}`},
+		{"currentDate timestamp", `{"$currentDate": {"updated_at": {"$type": "timestamp"}}}`},
+		{"push value", `{"$push": {"tags": "new"}}`},
+		{"push object value", `{"$push": {"events": {"kind": "login"}}}`},
+		{"push modifiers", `{"$push": {"scores": {"$each": [1, 2], "$sort": -1, "$slice": 5, "$position": 0}}}`},
+		{"addToSet each", `{"$addToSet": {"tags": {"$each": ["a", "b"]}}}`},
+		{"sibling paths", `{"$set": {"a.b": 1, "a.c": 2}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseUpdate([]byte(tt.raw), testUpdateOperators); err != nil {
+				t.Errorf("ParseUpdate(%s) returned error: %v", tt.raw, err)
+			}
+		})
+	}
+}
+
+func TestParseUpdateRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"blank", ""},
+		{"malformed", `{"$set": `},
+		{"empty", `{}`},
+		{"replacement document", `{"status": "done"}`},
+		{"operator not allowed", `{"$rename": {"a": "b"}}`},
+		{"$pull not in allowlist", `{"$pull": {"tags": "a"}}`},
+		{"unknown operator", `{"$where": {"a": 1}}`},
+		{"operator without fields", `{"$set": {}}`},
+		{"operator with scalar", `{"$set": "x"}`},
+		{"set _id", `{"$set": {"_id": 1}}`},
+		{"set _id sub-path", `{"$set": {"_id.part": 1}}`},
+		{"unset _id", `{"$unset": {"_id": ""}}`},
+		{"operator field name", `{"$set": {"$where": 1}}`},
+		{"dollar in path", `{"$set": {"a.$b": 1}}`},
+		{"same path twice", `{"$set": {"a": 1}, "$inc": {"a": 1}}`},
+		{"parent and child", `{"$set": {"a": {}}, "$unset": {"a.b": ""}}`},
+		{"child and parent", `{"$set": {"a.b": 1, "a": 2}}`},
+		{"inc string", `{"$inc": {"count": "1"}}`},
+		{"inc boolean", `{"$inc": {"count": true}}`},
+		{"currentDate false", `{"$currentDate": {"at": false}}`},
+		{"currentDate bad type", `{"$currentDate": {"at": {"$type": "string"}}}`},
+		{"currentDate extra key", `{"$currentDate": {"at": {"$type": "date", "x": 1}}}`},
+		{"push unknown modifier", `{"$push": {"tags": {"$each": [1], "$where": "1"}}}`},
+		{"addToSet slice", `{"$addToSet": {"tags": {"$each": [1], "$slice": 2}}}`},
+		{"too many fields", `{"$set": {` + manyUpdateFields(maxUpdateFields+1) + `}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseUpdate([]byte(tt.raw), testUpdateOperators); !errors.Is(err, ErrInvalidUpdate) {
+				t.Errorf("ParseUpdate(%s) error = %v, want ErrInvalidUpdate", tt.raw, err)
+			}
+		})
+	}
+}
+
+// manyUpdateFields returns n distinct "fN": 1 pairs
+func manyUpdateFields(n int) string {
+	fields := strings.Split(fieldList(n), ",")
+	for i, field := range fields {
+		fields[i] = `"` + field + `": 1`
+	}
+	return strings.Join(fields, ", ")
+}
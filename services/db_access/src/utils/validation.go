@@ -2,6 +2,7 @@ package utils
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -98,3 +99,19 @@ func IsValidUsername(username string) bool {
 func IsValidPassword(password string) bool {
 	return len(password) >= 6 && len(password) <= 128
 }
+
+// IsValidFieldPath checks a dotted field path used in filters, sorts and
+// projections: no empty segments, no operators and no null bytes
+func IsValidFieldPath(path string) bool {
+	if len(path) == 0 || len(path) > 256 || strings.ContainsRune(path, 0) {
+		return false
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" || strings.HasPrefix(segment, "$") {
+			return false
+		}
+	}
+
+	return true
+}